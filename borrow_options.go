@@ -0,0 +1,47 @@
+package pond
+
+import "time"
+
+//BorrowOption customize a single borrow, overriding the config of pool
+type BorrowOption func(o *borrowOptions)
+
+type borrowOptions struct {
+	nonblocking bool
+	noCreate    bool
+	maxWait     time.Duration
+	hasMaxWait  bool
+}
+
+func newBorrowOptions(opts []BorrowOption) borrowOptions {
+	if len(opts) == 0 {
+		return borrowOptions{}
+	}
+	var o borrowOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+//WithNonblocking return ErrPoolExhausted immediately when pool is exhausted, instead of waiting
+func WithNonblocking() BorrowOption {
+	return func(o *borrowOptions) {
+		o.nonblocking = true
+	}
+}
+
+//WithMaxWait limit the duration of waiting for a object, overriding Config.MaxWait.
+//ErrBorrowTimeout is returned when it's exceeded. If d <= 0, no limit except ctx.
+func WithMaxWait(d time.Duration) BorrowOption {
+	return func(o *borrowOptions) {
+		o.maxWait = d
+		o.hasMaxWait = true
+	}
+}
+
+//WithoutCreate borrow a idle object only, ErrNoIdleObject is returned if there is no any idle object
+func WithoutCreate() BorrowOption {
+	return func(o *borrowOptions) {
+		o.noCreate = true
+	}
+}
@@ -0,0 +1,128 @@
+package pond
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBorrowWithNonblocking(t *testing.T) {
+	ctx := context.Background()
+	cfg := NewConfig(testObjectCreateFactory)
+	cfg.MaxSize = 1
+	cfg.AutoEvict = false
+	p, _ := New(cfg)
+	defer p.Close(ctx)
+
+	obj, err := p.BorrowObject(ctx, WithNonblocking())
+	assert.NoError(t, err)
+	_, err = p.BorrowObject(ctx, WithNonblocking())
+	assert.Equal(t, ErrPoolExhausted, err)
+	assert.Equal(t, int64(1), p.Stats().Exhausted)
+	assert.NoError(t, p.ReturnObject(ctx, obj))
+}
+
+func TestBorrowWithMaxWait(t *testing.T) {
+	ctx := context.Background()
+	cfg := NewConfig(testObjectCreateFactory)
+	cfg.MaxSize = 1
+	cfg.MaxWait = time.Millisecond * 10
+	cfg.AutoEvict = false
+	p, _ := New(cfg)
+	defer p.Close(ctx)
+
+	obj, err := p.BorrowObject(ctx)
+	assert.NoError(t, err)
+
+	//by config
+	begin := time.Now()
+	_, err = p.BorrowObject(ctx)
+	assert.Equal(t, ErrBorrowTimeout, err)
+	assert.True(t, time.Since(begin) >= cfg.MaxWait)
+	assert.Equal(t, int64(1), p.Stats().BorrowTimeouts)
+
+	//ctx deadline take precedence over config
+	assert.NoError(t, p.UpdateConfig(func(config *Config) {
+		config.MaxWait = time.Hour
+	}))
+	cctx, cancel := context.WithTimeout(ctx, time.Millisecond*10)
+	defer cancel()
+	_, err = p.BorrowObject(cctx)
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	//option take precedence over config
+	_, err = p.BorrowObject(ctx, WithMaxWait(time.Millisecond*10))
+	assert.Equal(t, ErrBorrowTimeout, err)
+	assert.Equal(t, int64(3), p.Stats().BorrowTimeouts)
+	assert.Equal(t, 0, waitersLen(p.TypedPool))
+
+	//waken before timeout
+	go func() {
+		time.Sleep(time.Millisecond * 10)
+		_ = p.ReturnObject(ctx, obj)
+	}()
+	obj2, err := p.BorrowObject(ctx, WithMaxWait(time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, obj, obj2)
+	assert.NoError(t, p.ReturnObject(ctx, obj2))
+}
+
+func TestBorrowWithoutCreate(t *testing.T) {
+	ctx := context.Background()
+	cfg := NewConfig(testObjectCreateFactory)
+	cfg.MaxSize = 1
+	cfg.AutoEvict = false
+	valid := true
+	cfg.ObjectValidateFactory = func(ctx context.Context, object interface{}) bool {
+		return valid
+	}
+	p, _ := New(cfg)
+	defer p.Close(ctx)
+
+	_, err := p.BorrowObject(ctx, WithoutCreate())
+	assert.Equal(t, ErrNoIdleObject, err)
+	assert.Equal(t, int64(0), p.Stats().Creates)
+
+	obj, err := p.BorrowObject(ctx)
+	assert.NoError(t, err)
+	//never wait
+	_, err = p.BorrowObject(ctx, WithoutCreate())
+	assert.Equal(t, ErrNoIdleObject, err)
+	assert.NoError(t, p.ReturnObject(ctx, obj))
+
+	obj2, err := p.BorrowObject(ctx, WithoutCreate())
+	assert.NoError(t, err)
+	assert.Equal(t, obj, obj2)
+	assert.NoError(t, p.ReturnObject(ctx, obj2))
+
+	//the invalid idle object is not replaced
+	valid = false
+	_, err = p.BorrowObject(ctx, WithoutCreate())
+	assert.Equal(t, ErrNoIdleObject, err)
+	assert.Equal(t, 0, p.Size())
+	assert.Equal(t, int64(1), p.Stats().Creates)
+}
+
+func TestKeyedBorrowWithOptions(t *testing.T) {
+	ctx := context.Background()
+	cfg := NewKeyedConfig(func(key string) Config {
+		cfg := NewConfig(testObjectCreateFactory)
+		cfg.MaxSize = 1
+		return cfg
+	})
+	cfg.AutoEvict = false
+	p, _ := NewKeyed(cfg)
+	defer p.Close(ctx)
+
+	_, err := p.BorrowObject(ctx, "a", WithoutCreate())
+	assert.Equal(t, ErrNoIdleObject, err)
+	obj, err := p.BorrowObject(ctx, "a")
+	assert.NoError(t, err)
+	_, err = p.BorrowObject(ctx, "a", WithNonblocking())
+	assert.Equal(t, ErrPoolExhausted, err)
+	_, err = p.BorrowHandle(ctx, "a", WithMaxWait(time.Millisecond))
+	assert.Equal(t, ErrBorrowTimeout, err)
+	assert.NoError(t, p.ReturnObject(ctx, "a", obj))
+}
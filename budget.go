@@ -0,0 +1,85 @@
+package pond
+
+import (
+	"context"
+	"sync"
+)
+
+//budgetWaiter is the pool waiting for the budget released by others
+type budgetWaiter interface {
+	dispatchBudget()
+}
+
+//budget is the capacity shared by pools, e.g. the MaxTotal of keyed pool. It's thread-safe.
+//A pool acquire a unit for each slot, and release it when the slot freed.
+type budget struct {
+	lock    sync.Mutex
+	max     int //if max <= 0, no capacity limit
+	used    int
+	waiting map[budgetWaiter]struct{}
+	//reclaim free a unit by evicting a idle object of some pool, and report whether it's freed
+	reclaim func(ctx context.Context) bool
+}
+
+func newBudget(max int) *budget {
+	return &budget{
+		max:     max,
+		waiting: make(map[budgetWaiter]struct{}),
+	}
+}
+
+func (b *budget) isFull() bool {
+	return b.max > 0 && b.used >= b.max
+}
+
+//Acquire acquire a unit. If the budget is exhausted, the waiter will be notified when any unit released.
+func (b *budget) Acquire(w budgetWaiter) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.isFull() {
+		b.waiting[w] = struct{}{}
+		return false
+	}
+	b.used++
+	return true
+}
+
+//Release release a unit, and notify all waiting pools asynchronously.
+//It could be called with the lock of pool, since the waiters are notified without any lock.
+func (b *budget) Release() {
+	b.lock.Lock()
+	b.used--
+	if len(b.waiting) == 0 {
+		b.lock.Unlock()
+		return
+	}
+	waiting := make([]budgetWaiter, 0, len(b.waiting))
+	for w := range b.waiting {
+		waiting = append(waiting, w)
+	}
+	b.waiting = make(map[budgetWaiter]struct{})
+	b.lock.Unlock()
+
+	go func() {
+		for _, w := range waiting {
+			w.dispatchBudget()
+		}
+	}()
+}
+
+func (b *budget) Used() int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.used
+}
+
+//Reclaim try to free a unit if the budget is exhausted. It must be called without lock of pools.
+func (b *budget) Reclaim(ctx context.Context) bool {
+	b.lock.Lock()
+	full := b.isFull()
+	b.lock.Unlock()
+	if !full || b.reclaim == nil {
+		return false
+	}
+	return b.reclaim(ctx)
+}
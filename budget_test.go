@@ -0,0 +1,68 @@
+package pond
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testBudgetWaiter struct {
+	notified chan struct{}
+}
+
+func (w *testBudgetWaiter) dispatchBudget() {
+	w.notified <- struct{}{}
+}
+
+func TestBudget(t *testing.T) {
+	b := newBudget(2)
+	w := &testBudgetWaiter{notified: make(chan struct{}, 1)}
+	assert.True(t, b.Acquire(w))
+	assert.True(t, b.Acquire(w))
+	assert.False(t, b.Acquire(w))
+	assert.Equal(t, 2, b.Used())
+
+	b.Release()
+	select {
+	case <-w.notified:
+	case <-time.After(time.Second):
+		t.Fatal("waiter not notified")
+	}
+	assert.Equal(t, 1, b.Used())
+	assert.True(t, b.Acquire(w))
+
+	//notified only once
+	assert.False(t, b.Acquire(w))
+	b.Release()
+	<-w.notified
+	b.Release()
+	select {
+	case <-w.notified:
+		t.Fatal("waiter notified twice")
+	case <-time.After(time.Millisecond * 10):
+	}
+	assert.Equal(t, 0, b.Used())
+}
+
+func TestBudgetReclaim(t *testing.T) {
+	ctx := context.Background()
+	b := newBudget(1)
+	reclaimed := 0
+	b.reclaim = func(ctx context.Context) bool {
+		reclaimed++
+		return true
+	}
+	assert.False(t, b.Reclaim(ctx))
+	assert.True(t, b.Acquire(nil))
+	assert.True(t, b.Reclaim(ctx))
+	assert.Equal(t, 1, reclaimed)
+
+	//no limit
+	b = newBudget(0)
+	for i := 0; i < 10; i++ {
+		assert.True(t, b.Acquire(nil))
+	}
+	assert.Equal(t, 10, b.Used())
+}
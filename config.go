@@ -2,12 +2,34 @@ package pond
 
 import (
 	"context"
+	"fmt"
+	"reflect"
 	"time"
 )
 
-type ObjectCreateFactory func(ctx context.Context) (interface{}, error)
-type ObjectValidateFactory func(ctx context.Context, object interface{}) bool
-type ObjectDestroyFactory func(ctx context.Context, object interface{}) error
+type TypedObjectCreateFactory[T any] func(ctx context.Context) (T, error)
+type TypedObjectValidateFactory[T any] func(ctx context.Context, object T) bool
+type TypedObjectDestroyFactory[T any] func(ctx context.Context, object T) error
+type TypedObjectKeepaliveFactory[T any] func(ctx context.Context, object T) error
+
+type ObjectCreateFactory = TypedObjectCreateFactory[interface{}]
+type ObjectValidateFactory = TypedObjectValidateFactory[interface{}]
+type ObjectDestroyFactory = TypedObjectDestroyFactory[interface{}]
+type ObjectKeepaliveFactory = TypedObjectKeepaliveFactory[interface{}]
+
+//TypedAbandonedObject is the object borrowed longer than AbandonedTimeout
+type TypedAbandonedObject[T any] struct {
+	Object   T
+	BorrowAt time.Time
+	//Stack is the stack trace of the borrower, if AbandonedStackTrace enabled
+	Stack []byte
+	//Removed is true if the object has been reclaimed
+	Removed bool
+}
+type TypedAbandonedHandler[T any] func(ctx context.Context, abandoned TypedAbandonedObject[T])
+
+type AbandonedObject = TypedAbandonedObject[interface{}]
+type AbandonedHandler = TypedAbandonedHandler[interface{}]
 
 const (
 	DefaultMaxSize             = 10
@@ -15,11 +37,30 @@ const (
 	DefaultMaxIdle             = 10
 	DefaultMinIdleTime         = time.Minute * 5
 	DefaultNonblocking         = false
+	DefaultMaxWait             = time.Duration(0)
 	DefaultAutoEvict           = true
 	DefaultEvictInterval       = time.Second * 30
 	DefaultMaxValidateAttempts = 1
+	DefaultIdleOrder           = IdleOrderLIFO
+	DefaultTestWhileIdle       = false
+	DefaultTestsPerEvictRun    = 3
+
+	DefaultCreateBreakerThreshold      = 0
+	DefaultCreateBreakerOpenDuration   = time.Second * 5
+	DefaultCreateBreakerHalfOpenProbes = 1
+	DefaultMaxConcurrentCreates        = 0
+	DefaultCreateRate                  = 0
 )
 
+//DefaultCreateRetryPolicy doesn't retry, set MaxAttempts to enable it
+var DefaultCreateRetryPolicy = CreateRetryPolicy{
+	MaxAttempts:    1,
+	InitialBackoff: time.Millisecond * 100,
+	MaxBackoff:     time.Second * 2,
+	Multiplier:     2,
+	Jitter:         0.2,
+}
+
 var (
 	DefaultObjectValidateFactory ObjectValidateFactory = func(ctx context.Context, object interface{}) bool {
 		return true
@@ -29,7 +70,20 @@ var (
 	}
 )
 
-type Config struct {
+//isNopValidateFactory report whether the factory is nil or DefaultObjectValidateFactory, so that validating could be skipped
+func isNopValidateFactory[T any](factory TypedObjectValidateFactory[T]) bool {
+	return factory == nil || reflect.ValueOf(factory).Pointer() == reflect.ValueOf(DefaultObjectValidateFactory).Pointer()
+}
+
+//Config is the config of Pool
+type Config = TypedConfig[interface{}]
+
+//TypedConfig is the config of TypedPool
+type TypedConfig[T any] struct {
+	/**
+	The name of pool, e.g. for tracing.
+	*/
+	Name string
 	/**
 	The capacity of the pool. If MaxSize <= 0, no capacity limit.
 	*/
@@ -51,6 +105,11 @@ type Config struct {
 	*/
 	Nonblocking bool
 	/**
+	The default limit of waiting for a object when the ctx of borrowing has no deadline.
+	ErrBorrowTimeout is returned when it's exceeded. If MaxWait <= 0, no limit.
+	*/
+	MaxWait time.Duration
+	/**
 	Enable auto evict idle objects. When true, pool will create a goroutine to start a evictor.
 	*/
 	AutoEvict bool
@@ -63,17 +122,106 @@ type Config struct {
 	*/
 	MaxValidateAttempts int
 	/**
+	Validate object by ObjectValidateFactory when returned. If failed, the object will be destroyed immediately.
+	*/
+	ValidateOnReturn bool
+	/**
+	Test idle objects when evicting, by ObjectKeepaliveFactory or ObjectValidateFactory.
+	Failed objects will be destroyed, and replaced up to MinIdle.
+	*/
+	TestWhileIdle bool
+	/**
+	The maximal number of idle objects tested per evict. If TestsPerEvictRun <= 0, test all idle objects.
+	*/
+	TestsPerEvictRun int
+	/**
+	The maximal lifetime of object since created. If MaxLifetime <= 0, no lifetime limit.
+	Expired objects will be destroyed when returned, skipped when borrowed, and evicted when idle.
+	*/
+	MaxLifetime time.Duration
+	/**
+	The maximal random duration subtracted from MaxLifetime of each object, so that objects won't expire at the same time.
+	*/
+	LifetimeJitter time.Duration
+	/**
+	The maximal times that object could be borrowed. If MaxUses <= 0, no uses limit.
+	Objects used up will be destroyed when returned.
+	*/
+	MaxUses int
+	/**
+	Objects borrowed longer than AbandonedTimeout are abandoned, and reported to OnAbandoned by evictor.
+	If AbandonedTimeout <= 0, abandoned objects detection is disabled.
+	*/
+	AbandonedTimeout time.Duration
+	/**
+	Reclaim the abandoned objects, they will be destroyed and their slots will be freed.
+	*/
+	RemoveAbandoned bool
+	/**
+	Capture the stack trace of borrower, so that it could be reported with abandoned object.
+	*/
+	AbandonedStackTrace bool
+	/**
+	The handler of abandoned objects.
+	*/
+	OnAbandoned TypedAbandonedHandler[T]
+	/**
+	The consecutive failures of ObjectCreateFactory to open the circuit of creating objects.
+	While it's open, borrowers needing a new object fail fast with ErrCreateCircuitOpen wrapping the last create error.
+	If CreateBreakerThreshold <= 0, the circuit breaker is disabled.
+	*/
+	CreateBreakerThreshold int
+	/**
+	The duration that the circuit keeps open, then it's half-open to probe by creating.
+	*/
+	CreateBreakerOpenDuration time.Duration
+	/**
+	The number of creating allowed when the circuit is half-open. It's closed if all of them succeed, or open again if any fails.
+	*/
+	CreateBreakerHalfOpenProbes int
+	/**
+	The maximal number of ObjectCreateFactory calls running at the same time. If MaxConcurrentCreates <= 0, no concurrency limit.
+	Borrowers beyond the limit wait for a new object or a returned one, whichever comes first.
+	*/
+	MaxConcurrentCreates int
+	/**
+	The maximal number of ObjectCreateFactory calls per second, limited by a token bucket holding up to one second of tokens.
+	Borrowers beyond the rate wait like MaxConcurrentCreates. If CreateRate <= 0, no rate limit.
+	*/
+	CreateRate float64
+	/**
+	The policy of retrying ObjectCreateFactory when borrowing, so that the transient errors are not returned to borrowers.
+	Retries are made with the slot of borrower, and stopped before the deadline of borrowing ctx.
+	*/
+	CreateRetryPolicy CreateRetryPolicy
+	/**
+	The order of borrowing idle objects, IdleOrderLIFO or IdleOrderFIFO.
+	*/
+	IdleOrder IdleOrder
+	/**
+	The listener of pool lifecycle events, e.g. for logging and alerting.
+	*/
+	Listener TypedPoolListener[T]
+	/**
+	The tracer of the wait, create and validate phases in borrowing.
+	*/
+	Tracer Tracer
+	/**
 	The factory of creating object.
 	*/
-	ObjectCreateFactory ObjectCreateFactory
+	ObjectCreateFactory TypedObjectCreateFactory[T]
 	/**
 	The factory of validating object.
 	*/
-	ObjectValidateFactory ObjectValidateFactory
+	ObjectValidateFactory TypedObjectValidateFactory[T]
 	/**
 	The factory of destroying object.
 	*/
-	ObjectDestroyFactory ObjectDestroyFactory
+	ObjectDestroyFactory TypedObjectDestroyFactory[T]
+	/**
+	The factory of keeping idle object alive, e.g. ping. If nil, ObjectValidateFactory is used to test idle objects.
+	*/
+	ObjectKeepaliveFactory TypedObjectKeepaliveFactory[T]
 }
 
 func NewConfig(objectCreateFactory ObjectCreateFactory) Config {
@@ -83,17 +231,108 @@ func NewConfig(objectCreateFactory ObjectCreateFactory) Config {
 }
 
 func NewDefaultConfig() Config {
-	return Config{
+	cfg := NewTypedDefaultConfig[interface{}]()
+	cfg.ObjectValidateFactory = DefaultObjectValidateFactory
+	cfg.ObjectDestroyFactory = DefaultObjectDestroyFactory
+	return cfg
+}
+
+func NewTypedConfig[T any](objectCreateFactory TypedObjectCreateFactory[T]) TypedConfig[T] {
+	cfg := NewTypedDefaultConfig[T]()
+	cfg.ObjectCreateFactory = objectCreateFactory
+	return cfg
+}
+
+//NewTypedDefaultConfig create a default config without validate and destroy factories
+func NewTypedDefaultConfig[T any]() TypedConfig[T] {
+	return TypedConfig[T]{
 		MaxSize:             DefaultMaxSize,
 		MinIdle:             DefaultMinIdle,
 		MaxIdle:             DefaultMaxIdle,
 		MinIdleTime:         DefaultMinIdleTime,
 		Nonblocking:         DefaultNonblocking,
+		MaxWait:             DefaultMaxWait,
 		AutoEvict:           DefaultAutoEvict,
 		EvictInterval:       DefaultEvictInterval,
 		MaxValidateAttempts: DefaultMaxValidateAttempts,
+		IdleOrder:           DefaultIdleOrder,
+		TestWhileIdle:       DefaultTestWhileIdle,
+		TestsPerEvictRun:    DefaultTestsPerEvictRun,
+
+		CreateBreakerThreshold:      DefaultCreateBreakerThreshold,
+		CreateBreakerOpenDuration:   DefaultCreateBreakerOpenDuration,
+		CreateBreakerHalfOpenProbes: DefaultCreateBreakerHalfOpenProbes,
+		MaxConcurrentCreates:        DefaultMaxConcurrentCreates,
+		CreateRate:                  DefaultCreateRate,
+		CreateRetryPolicy:           DefaultCreateRetryPolicy,
+	}
+}
+
+//ConfigError describes the invalid field of config
+type ConfigError struct {
+	Field  string
+	Reason string
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("invalid config %s: %s", e.Field, e.Reason)
+}
+
+func newConfigError(field string, format string, args ...interface{}) *ConfigError {
+	return &ConfigError{Field: field, Reason: fmt.Sprintf(format, args...)}
+}
 
-		ObjectValidateFactory: DefaultObjectValidateFactory,
-		ObjectDestroyFactory:  DefaultObjectDestroyFactory,
+//Validate check the config strictly, and return a *ConfigError describing the first invalid field
+func (c TypedConfig[T]) Validate() error {
+	switch {
+	case c.ObjectCreateFactory == nil:
+		return newConfigError("ObjectCreateFactory", "is required")
+	case c.MinIdle < 0:
+		return newConfigError("MinIdle", "must not be negative, got %d", c.MinIdle)
+	case c.MaxIdle < 0:
+		return newConfigError("MaxIdle", "must not be negative, got %d", c.MaxIdle)
+	case c.MinIdle > c.MaxIdle:
+		return newConfigError("MinIdle", "must not be greater than MaxIdle %d, got %d", c.MaxIdle, c.MinIdle)
+	case c.MaxSize > 0 && c.MinIdle > c.MaxSize:
+		return newConfigError("MinIdle", "must not be greater than MaxSize %d, got %d", c.MaxSize, c.MinIdle)
+	case c.MinIdleTime < 0:
+		return newConfigError("MinIdleTime", "must not be negative, got %v", c.MinIdleTime)
+	case c.MaxWait < 0:
+		return newConfigError("MaxWait", "must not be negative, got %v", c.MaxWait)
+	case c.AutoEvict && c.EvictInterval <= 0:
+		return newConfigError("EvictInterval", "must be positive when AutoEvict enabled, got %v", c.EvictInterval)
+	case c.MaxValidateAttempts < 0:
+		return newConfigError("MaxValidateAttempts", "must not be negative, got %d", c.MaxValidateAttempts)
+	case c.MaxLifetime < 0:
+		return newConfigError("MaxLifetime", "must not be negative, got %v", c.MaxLifetime)
+	case c.LifetimeJitter < 0:
+		return newConfigError("LifetimeJitter", "must not be negative, got %v", c.LifetimeJitter)
+	case c.MaxLifetime > 0 && c.LifetimeJitter >= c.MaxLifetime:
+		return newConfigError("LifetimeJitter", "must be less than MaxLifetime %v, got %v", c.MaxLifetime, c.LifetimeJitter)
+	case c.MaxUses < 0:
+		return newConfigError("MaxUses", "must not be negative, got %d", c.MaxUses)
+	case c.AbandonedTimeout < 0:
+		return newConfigError("AbandonedTimeout", "must not be negative, got %v", c.AbandonedTimeout)
+	case c.CreateBreakerThreshold > 0 && c.CreateBreakerOpenDuration <= 0:
+		return newConfigError("CreateBreakerOpenDuration", "must be positive when CreateBreakerThreshold > 0, got %v", c.CreateBreakerOpenDuration)
+	case c.CreateBreakerThreshold > 0 && c.CreateBreakerHalfOpenProbes <= 0:
+		return newConfigError("CreateBreakerHalfOpenProbes", "must be positive when CreateBreakerThreshold > 0, got %d", c.CreateBreakerHalfOpenProbes)
+	case c.MaxConcurrentCreates < 0:
+		return newConfigError("MaxConcurrentCreates", "must not be negative, got %d", c.MaxConcurrentCreates)
+	case c.CreateRate < 0:
+		return newConfigError("CreateRate", "must not be negative, got %v", c.CreateRate)
+	case c.CreateRetryPolicy.MaxAttempts < 0:
+		return newConfigError("CreateRetryPolicy.MaxAttempts", "must not be negative, got %d", c.CreateRetryPolicy.MaxAttempts)
+	case c.CreateRetryPolicy.InitialBackoff < 0:
+		return newConfigError("CreateRetryPolicy.InitialBackoff", "must not be negative, got %v", c.CreateRetryPolicy.InitialBackoff)
+	case c.CreateRetryPolicy.MaxBackoff < 0:
+		return newConfigError("CreateRetryPolicy.MaxBackoff", "must not be negative, got %v", c.CreateRetryPolicy.MaxBackoff)
+	case c.CreateRetryPolicy.MaxAttempts > 1 && c.CreateRetryPolicy.Multiplier < 1:
+		return newConfigError("CreateRetryPolicy.Multiplier", "must not be less than 1 when MaxAttempts > 1, got %v", c.CreateRetryPolicy.Multiplier)
+	case c.CreateRetryPolicy.Jitter < 0 || c.CreateRetryPolicy.Jitter > 1:
+		return newConfigError("CreateRetryPolicy.Jitter", "must be in [0, 1], got %v", c.CreateRetryPolicy.Jitter)
+	case c.IdleOrder != IdleOrderLIFO && c.IdleOrder != IdleOrderFIFO:
+		return newConfigError("IdleOrder", "must be IdleOrderLIFO or IdleOrderFIFO, got %v", c.IdleOrder)
 	}
+	return nil
 }
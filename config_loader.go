@@ -0,0 +1,262 @@
+package pond
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+//Duration is a time.Duration unmarshalled from human-readable string like "30s" or "5m", or nanoseconds number
+type Duration time.Duration
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	if nanos, err := strconv.ParseInt(string(text), 10, 64); err == nil {
+		*d = Duration(nanos)
+		return nil
+	}
+	duration, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(duration)
+	return nil
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		var text string
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+		return d.UnmarshalText([]byte(text))
+	}
+	var nanos int64
+	if err := json.Unmarshal(data, &nanos); err != nil {
+		return fmt.Errorf("duration should be a string like \"30s\" or nanoseconds: %v", err)
+	}
+	*d = Duration(nanos)
+	return nil
+}
+
+//configOptions is the serializable fields of config
+type configOptions struct {
+	Name                string    `json:"name" yaml:"name" env:"NAME"`
+	MaxSize             int       `json:"maxSize" yaml:"maxSize" env:"MAX_SIZE"`
+	MinIdle             int       `json:"minIdle" yaml:"minIdle" env:"MIN_IDLE"`
+	MaxIdle             int       `json:"maxIdle" yaml:"maxIdle" env:"MAX_IDLE"`
+	MinIdleTime         Duration  `json:"minIdleTime" yaml:"minIdleTime" env:"MIN_IDLE_TIME"`
+	Nonblocking         bool      `json:"nonblocking" yaml:"nonblocking" env:"NONBLOCKING"`
+	MaxWait             Duration  `json:"maxWait" yaml:"maxWait" env:"MAX_WAIT"`
+	AutoEvict           bool      `json:"autoEvict" yaml:"autoEvict" env:"AUTO_EVICT"`
+	EvictInterval       Duration  `json:"evictInterval" yaml:"evictInterval" env:"EVICT_INTERVAL"`
+	MaxValidateAttempts int       `json:"maxValidateAttempts" yaml:"maxValidateAttempts" env:"MAX_VALIDATE_ATTEMPTS"`
+	ValidateOnReturn    bool      `json:"validateOnReturn" yaml:"validateOnReturn" env:"VALIDATE_ON_RETURN"`
+	TestWhileIdle       bool      `json:"testWhileIdle" yaml:"testWhileIdle" env:"TEST_WHILE_IDLE"`
+	TestsPerEvictRun    int       `json:"testsPerEvictRun" yaml:"testsPerEvictRun" env:"TESTS_PER_EVICT_RUN"`
+	MaxLifetime         Duration  `json:"maxLifetime" yaml:"maxLifetime" env:"MAX_LIFETIME"`
+	LifetimeJitter      Duration  `json:"lifetimeJitter" yaml:"lifetimeJitter" env:"LIFETIME_JITTER"`
+	MaxUses             int       `json:"maxUses" yaml:"maxUses" env:"MAX_USES"`
+	AbandonedTimeout    Duration  `json:"abandonedTimeout" yaml:"abandonedTimeout" env:"ABANDONED_TIMEOUT"`
+	RemoveAbandoned     bool      `json:"removeAbandoned" yaml:"removeAbandoned" env:"REMOVE_ABANDONED"`
+	AbandonedStackTrace bool      `json:"abandonedStackTrace" yaml:"abandonedStackTrace" env:"ABANDONED_STACK_TRACE"`
+	IdleOrder           IdleOrder `json:"idleOrder" yaml:"idleOrder" env:"IDLE_ORDER"`
+
+	CreateBreakerThreshold      int      `json:"createBreakerThreshold" yaml:"createBreakerThreshold" env:"CREATE_BREAKER_THRESHOLD"`
+	CreateBreakerOpenDuration   Duration `json:"createBreakerOpenDuration" yaml:"createBreakerOpenDuration" env:"CREATE_BREAKER_OPEN_DURATION"`
+	CreateBreakerHalfOpenProbes int      `json:"createBreakerHalfOpenProbes" yaml:"createBreakerHalfOpenProbes" env:"CREATE_BREAKER_HALF_OPEN_PROBES"`
+	MaxConcurrentCreates        int      `json:"maxConcurrentCreates" yaml:"maxConcurrentCreates" env:"MAX_CONCURRENT_CREATES"`
+	CreateRate                  float64  `json:"createRate" yaml:"createRate" env:"CREATE_RATE"`
+	CreateRetryMaxAttempts      int      `json:"createRetryMaxAttempts" yaml:"createRetryMaxAttempts" env:"CREATE_RETRY_MAX_ATTEMPTS"`
+	CreateRetryInitialBackoff   Duration `json:"createRetryInitialBackoff" yaml:"createRetryInitialBackoff" env:"CREATE_RETRY_INITIAL_BACKOFF"`
+	CreateRetryMaxBackoff       Duration `json:"createRetryMaxBackoff" yaml:"createRetryMaxBackoff" env:"CREATE_RETRY_MAX_BACKOFF"`
+	CreateRetryMultiplier       float64  `json:"createRetryMultiplier" yaml:"createRetryMultiplier" env:"CREATE_RETRY_MULTIPLIER"`
+	CreateRetryJitter           float64  `json:"createRetryJitter" yaml:"createRetryJitter" env:"CREATE_RETRY_JITTER"`
+}
+
+func newConfigOptions[T any](c TypedConfig[T]) configOptions {
+	return configOptions{
+		Name:                c.Name,
+		MaxSize:             c.MaxSize,
+		MinIdle:             c.MinIdle,
+		MaxIdle:             c.MaxIdle,
+		MinIdleTime:         Duration(c.MinIdleTime),
+		Nonblocking:         c.Nonblocking,
+		MaxWait:             Duration(c.MaxWait),
+		AutoEvict:           c.AutoEvict,
+		EvictInterval:       Duration(c.EvictInterval),
+		MaxValidateAttempts: c.MaxValidateAttempts,
+		ValidateOnReturn:    c.ValidateOnReturn,
+		TestWhileIdle:       c.TestWhileIdle,
+		TestsPerEvictRun:    c.TestsPerEvictRun,
+		MaxLifetime:         Duration(c.MaxLifetime),
+		LifetimeJitter:      Duration(c.LifetimeJitter),
+		MaxUses:             c.MaxUses,
+		AbandonedTimeout:    Duration(c.AbandonedTimeout),
+		RemoveAbandoned:     c.RemoveAbandoned,
+		AbandonedStackTrace: c.AbandonedStackTrace,
+		IdleOrder:           c.IdleOrder,
+
+		CreateBreakerThreshold:      c.CreateBreakerThreshold,
+		CreateBreakerOpenDuration:   Duration(c.CreateBreakerOpenDuration),
+		CreateBreakerHalfOpenProbes: c.CreateBreakerHalfOpenProbes,
+		MaxConcurrentCreates:        c.MaxConcurrentCreates,
+		CreateRate:                  c.CreateRate,
+		CreateRetryMaxAttempts:      c.CreateRetryPolicy.MaxAttempts,
+		CreateRetryInitialBackoff:   Duration(c.CreateRetryPolicy.InitialBackoff),
+		CreateRetryMaxBackoff:       Duration(c.CreateRetryPolicy.MaxBackoff),
+		CreateRetryMultiplier:       c.CreateRetryPolicy.Multiplier,
+		CreateRetryJitter:           c.CreateRetryPolicy.Jitter,
+	}
+}
+
+//applyOptions copy the options to config, the factories, handlers and classifiers are kept
+func applyOptions[T any](c *TypedConfig[T], o configOptions) {
+	c.Name = o.Name
+	c.MaxSize = o.MaxSize
+	c.MinIdle = o.MinIdle
+	c.MaxIdle = o.MaxIdle
+	c.MinIdleTime = time.Duration(o.MinIdleTime)
+	c.Nonblocking = o.Nonblocking
+	c.MaxWait = time.Duration(o.MaxWait)
+	c.AutoEvict = o.AutoEvict
+	c.EvictInterval = time.Duration(o.EvictInterval)
+	c.MaxValidateAttempts = o.MaxValidateAttempts
+	c.ValidateOnReturn = o.ValidateOnReturn
+	c.TestWhileIdle = o.TestWhileIdle
+	c.TestsPerEvictRun = o.TestsPerEvictRun
+	c.MaxLifetime = time.Duration(o.MaxLifetime)
+	c.LifetimeJitter = time.Duration(o.LifetimeJitter)
+	c.MaxUses = o.MaxUses
+	c.AbandonedTimeout = time.Duration(o.AbandonedTimeout)
+	c.RemoveAbandoned = o.RemoveAbandoned
+	c.AbandonedStackTrace = o.AbandonedStackTrace
+	c.IdleOrder = o.IdleOrder
+	c.CreateBreakerThreshold = o.CreateBreakerThreshold
+	c.CreateBreakerOpenDuration = time.Duration(o.CreateBreakerOpenDuration)
+	c.CreateBreakerHalfOpenProbes = o.CreateBreakerHalfOpenProbes
+	c.MaxConcurrentCreates = o.MaxConcurrentCreates
+	c.CreateRate = o.CreateRate
+	c.CreateRetryPolicy.MaxAttempts = o.CreateRetryMaxAttempts
+	c.CreateRetryPolicy.InitialBackoff = time.Duration(o.CreateRetryInitialBackoff)
+	c.CreateRetryPolicy.MaxBackoff = time.Duration(o.CreateRetryMaxBackoff)
+	c.CreateRetryPolicy.Multiplier = o.CreateRetryMultiplier
+	c.CreateRetryPolicy.Jitter = o.CreateRetryJitter
+}
+
+//UnmarshalJSON overwrite the fields present in JSON, the others are kept. Unknown fields are rejected.
+//Durations could be human-readable strings like "30s", or numbers of nanoseconds.
+func (c *TypedConfig[T]) UnmarshalJSON(data []byte) error {
+	options := newConfigOptions(*c)
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&options); err != nil {
+		return err
+	}
+	applyOptions(c, options)
+	return nil
+}
+
+//UnmarshalYAML overwrite the fields present in YAML, the others are kept.
+//Durations could be human-readable strings like "30s", or numbers of nanoseconds.
+func (c *TypedConfig[T]) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	options := newConfigOptions(*c)
+	if err := unmarshal(&options); err != nil {
+		return err
+	}
+	applyOptions(c, options)
+	return nil
+}
+
+//LoadFile overwrite the config by JSON or YAML file, the format is decided by extension.
+//Unknown fields are rejected. It doesn't validate the config, call Validate after the factories set.
+func (c *TypedConfig[T]) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return c.unmarshalFile(path, data)
+}
+
+func (c *TypedConfig[T]) unmarshalFile(path string, data []byte) error {
+	var err error
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		err = json.Unmarshal(data, c)
+	case ".yaml", ".yml":
+		err = yaml.UnmarshalStrict(data, c)
+	default:
+		return fmt.Errorf("unsupported config file %s, .json, .yaml or .yml expected", path)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid config file %s: %v", path, err)
+	}
+	return nil
+}
+
+//LoadEnv overwrite the config by the environment variables with prefix, e.g. POND_MAX_SIZE=10 if prefix is "POND".
+//It doesn't validate the config, call Validate after the factories set.
+func (c *TypedConfig[T]) LoadEnv(prefix string) error {
+	if prefix != "" && !strings.HasSuffix(prefix, "_") {
+		prefix += "_"
+	}
+	options := newConfigOptions(*c)
+	v := reflect.ValueOf(&options).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		key := prefix + v.Type().Field(i).Tag.Get("env")
+		value, ok := os.LookupEnv(key)
+		if !ok {
+			continue
+		}
+		if err := setOption(v.Field(i), value); err != nil {
+			return fmt.Errorf("invalid env %s=%q: %v", key, value, err)
+		}
+	}
+	applyOptions(c, options)
+	return nil
+}
+
+func setOption(field reflect.Value, value string) error {
+	if u, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(value))
+	}
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(n))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	}
+	return nil
+}
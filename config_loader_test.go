@@ -0,0 +1,165 @@
+package pond
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func TestConfigUnmarshalJSON(t *testing.T) {
+	cfg := NewConfig(testObjectCreateFactory)
+	err := json.Unmarshal([]byte(`{
+		"name": "mysql",
+		"maxSize": 20,
+		"minIdle": 2,
+		"minIdleTime": "1m30s",
+		"evictInterval": 1000000000,
+		"idleOrder": "FIFO"
+	}`), &cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, "mysql", cfg.Name)
+	assert.Equal(t, 20, cfg.MaxSize)
+	assert.Equal(t, 2, cfg.MinIdle)
+	assert.Equal(t, time.Second*90, cfg.MinIdleTime)
+	assert.Equal(t, time.Second, cfg.EvictInterval)
+	assert.Equal(t, IdleOrderFIFO, cfg.IdleOrder)
+	//the others are kept
+	assert.Equal(t, DefaultMaxIdle, cfg.MaxIdle)
+	assert.NotNil(t, cfg.ObjectCreateFactory)
+	assert.NoError(t, cfg.Validate())
+
+	assert.Error(t, json.Unmarshal([]byte(`{"maxSise": 20}`), &cfg))
+	assert.Error(t, json.Unmarshal([]byte(`{"minIdleTime": "1 minute"}`), &cfg))
+	assert.Error(t, json.Unmarshal([]byte(`{"idleOrder": "random"}`), &cfg))
+}
+
+func TestConfigUnmarshalYAML(t *testing.T) {
+	cfg := NewConfig(testObjectCreateFactory)
+	err := yaml.UnmarshalStrict([]byte(`
+maxSize: 20
+maxIdle: 5
+maxLifetime: 1h
+idleOrder: fifo
+createBreakerThreshold: 5
+createBreakerOpenDuration: 10s
+createRetryMaxAttempts: 3
+createRetryInitialBackoff: 50ms
+minIdleTime: 1000
+`), &cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, 20, cfg.MaxSize)
+	assert.Equal(t, 5, cfg.MaxIdle)
+	assert.Equal(t, time.Hour, cfg.MaxLifetime)
+	assert.Equal(t, IdleOrderFIFO, cfg.IdleOrder)
+	assert.Equal(t, 5, cfg.CreateBreakerThreshold)
+	assert.Equal(t, time.Second*10, cfg.CreateBreakerOpenDuration)
+	assert.Equal(t, DefaultCreateBreakerHalfOpenProbes, cfg.CreateBreakerHalfOpenProbes)
+	assert.Equal(t, 3, cfg.CreateRetryPolicy.MaxAttempts)
+	assert.Equal(t, time.Millisecond*50, cfg.CreateRetryPolicy.InitialBackoff)
+	assert.Equal(t, DefaultCreateRetryPolicy.Multiplier, cfg.CreateRetryPolicy.Multiplier)
+	assert.Equal(t, time.Microsecond, cfg.MinIdleTime)
+	assert.Equal(t, DefaultEvictInterval, cfg.EvictInterval)
+
+	assert.Error(t, yaml.UnmarshalStrict([]byte(`maxSise: 20`), &cfg))
+	assert.Error(t, yaml.UnmarshalStrict([]byte(`minIdleTime: 1 minute`), &cfg))
+}
+
+func TestConfigLoadFile(t *testing.T) {
+	dir := t.TempDir()
+	cfg := NewConfig(testObjectCreateFactory)
+
+	path := filepath.Join(dir, "pool.yml")
+	assert.NoError(t, os.WriteFile(path, []byte("maxSize: 3\nminIdleTime: 10s\n"), 0644))
+	assert.NoError(t, cfg.LoadFile(path))
+	assert.Equal(t, 3, cfg.MaxSize)
+	assert.Equal(t, time.Second*10, cfg.MinIdleTime)
+
+	path = filepath.Join(dir, "pool.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{"maxSize": 4}`), 0644))
+	assert.NoError(t, cfg.LoadFile(path))
+	assert.Equal(t, 4, cfg.MaxSize)
+
+	path = filepath.Join(dir, "pool.toml")
+	assert.NoError(t, os.WriteFile(path, []byte(`maxSize = 5`), 0644))
+	assert.Error(t, cfg.LoadFile(path))
+	assert.Error(t, cfg.LoadFile(filepath.Join(dir, "none.json")))
+}
+
+func TestConfigLoadEnv(t *testing.T) {
+	t.Setenv("POND_MAX_SIZE", "30")
+	t.Setenv("POND_NONBLOCKING", "true")
+	t.Setenv("POND_EVICT_INTERVAL", "10s")
+	t.Setenv("POND_IDLE_ORDER", "fifo")
+	t.Setenv("POND_NAME", "redis")
+	t.Setenv("POND_CREATE_RATE", "2.5")
+	t.Setenv("POND_MAX_WAIT", "1000000")
+
+	cfg := NewConfig(testObjectCreateFactory)
+	assert.NoError(t, cfg.LoadEnv("POND"))
+	assert.Equal(t, 30, cfg.MaxSize)
+	assert.True(t, cfg.Nonblocking)
+	assert.Equal(t, time.Second*10, cfg.EvictInterval)
+	assert.Equal(t, IdleOrderFIFO, cfg.IdleOrder)
+	assert.Equal(t, "redis", cfg.Name)
+	assert.Equal(t, 2.5, cfg.CreateRate)
+	assert.Equal(t, time.Millisecond, cfg.MaxWait)
+	assert.Equal(t, DefaultMaxIdle, cfg.MaxIdle)
+
+	t.Setenv("POND_MAX_IDLE", "ten")
+	err := cfg.LoadEnv("POND_")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "POND_MAX_IDLE")
+}
+
+func TestConfigValidate(t *testing.T) {
+	assert.NoError(t, NewConfig(testObjectCreateFactory).Validate())
+	assert.Equal(t, &ConfigError{Field: "ObjectCreateFactory", Reason: "is required"}, NewDefaultConfig().Validate())
+
+	cases := []struct {
+		field  string
+		update func(cfg *Config)
+	}{
+		{"MinIdle", func(cfg *Config) { cfg.MinIdle = -1 }},
+		{"MaxIdle", func(cfg *Config) { cfg.MaxIdle = -1 }},
+		{"MinIdle", func(cfg *Config) { cfg.MinIdle = cfg.MaxIdle + 1 }},
+		{"MinIdle", func(cfg *Config) { cfg.MaxSize = 1; cfg.MinIdle = 2 }},
+		{"MinIdleTime", func(cfg *Config) { cfg.MinIdleTime = -1 }},
+		{"EvictInterval", func(cfg *Config) { cfg.EvictInterval = 0 }},
+		{"MaxValidateAttempts", func(cfg *Config) { cfg.MaxValidateAttempts = -1 }},
+		{"MaxLifetime", func(cfg *Config) { cfg.MaxLifetime = -1 }},
+		{"LifetimeJitter", func(cfg *Config) { cfg.LifetimeJitter = -1 }},
+		{"LifetimeJitter", func(cfg *Config) { cfg.MaxLifetime = time.Second; cfg.LifetimeJitter = time.Second }},
+		{"MaxUses", func(cfg *Config) { cfg.MaxUses = -1 }},
+		{"AbandonedTimeout", func(cfg *Config) { cfg.AbandonedTimeout = -1 }},
+		{"CreateBreakerOpenDuration", func(cfg *Config) { cfg.CreateBreakerThreshold = 1; cfg.CreateBreakerOpenDuration = 0 }},
+		{"CreateBreakerHalfOpenProbes", func(cfg *Config) { cfg.CreateBreakerThreshold = 1; cfg.CreateBreakerHalfOpenProbes = 0 }},
+		{"MaxConcurrentCreates", func(cfg *Config) { cfg.MaxConcurrentCreates = -1 }},
+		{"CreateRate", func(cfg *Config) { cfg.CreateRate = -1 }},
+		{"CreateRetryPolicy.MaxAttempts", func(cfg *Config) { cfg.CreateRetryPolicy.MaxAttempts = -1 }},
+		{"CreateRetryPolicy.InitialBackoff", func(cfg *Config) { cfg.CreateRetryPolicy.InitialBackoff = -1 }},
+		{"CreateRetryPolicy.MaxBackoff", func(cfg *Config) { cfg.CreateRetryPolicy.MaxBackoff = -1 }},
+		{"CreateRetryPolicy.Multiplier", func(cfg *Config) { cfg.CreateRetryPolicy.MaxAttempts = 2; cfg.CreateRetryPolicy.Multiplier = 0.5 }},
+		{"CreateRetryPolicy.Jitter", func(cfg *Config) { cfg.CreateRetryPolicy.Jitter = 2 }},
+		{"IdleOrder", func(cfg *Config) { cfg.IdleOrder = 2 }},
+	}
+	for _, c := range cases {
+		cfg := NewConfig(testObjectCreateFactory)
+		c.update(&cfg)
+		err := cfg.Validate()
+		if assert.IsType(t, &ConfigError{}, err) {
+			assert.Equal(t, c.field, err.(*ConfigError).Field)
+		}
+	}
+
+	//no limit
+	cfg := NewConfig(testObjectCreateFactory)
+	cfg.MaxSize = 0
+	cfg.AutoEvict = false
+	cfg.EvictInterval = 0
+	assert.NoError(t, cfg.Validate())
+}
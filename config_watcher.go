@@ -0,0 +1,102 @@
+package pond
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+//ConfigWatcher polls the config file, and applies it to the pool when changed
+type ConfigWatcher struct {
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+//WatchConfigFile apply the JSON or YAML config file to the pool, and poll its modification every interval.
+//Only the fields could be updated at runtime take effect, see UpdateConfig.
+//The errors of loading or applying changes are reported to onError if not nil, and the pool is kept unchanged.
+//If the file could not be stat, e.g. deleted or renamed, the error is reported once until it changes.
+//The watcher exits by itself at the next poll after the pool is closed.
+func (p *TypedPool[T]) WatchConfigFile(path string, interval time.Duration, onError func(err error)) (*ConfigWatcher, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.applyConfigFile(path); err != nil {
+		return nil, err
+	}
+	w := &ConfigWatcher{
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	report := func(err error) {
+		if onError != nil {
+			onError(err)
+		}
+	}
+	go w.run(path, interval, info, p.isClosed, report, func() bool {
+		err := p.applyConfigFile(path)
+		if err == ErrPoolClosed {
+			return false
+		}
+		if err != nil {
+			report(err)
+		}
+		return true
+	})
+	return w, nil
+}
+
+func (p *TypedPool[T]) applyConfigFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return p.updateConfig(func(config *TypedConfig[T]) error {
+		return config.unmarshalFile(path, data)
+	})
+}
+
+//run call apply when the file changed, until stopped, closed or apply return false.
+//The errors of stat are reported when the error state changes.
+func (w *ConfigWatcher) run(path string, interval time.Duration, last os.FileInfo, closed func() bool, report func(err error), apply func() bool) {
+	defer close(w.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var statErr error
+	for {
+		select {
+		case <-ticker.C:
+		case <-w.stop:
+			return
+		}
+		if closed() {
+			return
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			if statErr == nil || statErr.Error() != err.Error() {
+				report(err)
+			}
+			statErr = err
+			continue
+		}
+		statErr = nil
+		if info.ModTime().Equal(last.ModTime()) && info.Size() == last.Size() {
+			continue
+		}
+		last = info
+		if !apply() {
+			return
+		}
+	}
+}
+
+//Stop stop watching, and wait until the watcher exits
+func (w *ConfigWatcher) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.stop)
+	})
+	<-w.done
+}
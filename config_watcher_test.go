@@ -0,0 +1,108 @@
+package pond
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPoolWatchConfigFile(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "pool.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte("maxSize: 2\n"), 0644))
+
+	cfg := NewConfig(testObjectCreateFactory)
+	cfg.AutoEvict = false
+	p, _ := New(cfg)
+	defer p.Close(ctx)
+
+	var lock sync.Mutex
+	var errs []error
+	w, err := p.WatchConfigFile(path, time.Millisecond, func(err error) {
+		lock.Lock()
+		errs = append(errs, err)
+		lock.Unlock()
+	})
+	assert.NoError(t, err)
+	defer w.Stop()
+	assert.Equal(t, 2, p.Stats().MaxSize)
+
+	//applied when changed
+	assert.NoError(t, os.WriteFile(path, []byte("maxSize: 5\nmaxIdle: 3\n"), 0644))
+	assert.True(t, waitUntil(time.Second, func() bool {
+		return p.Stats().MaxSize == 5
+	}))
+	assert.Equal(t, 3, p.Stats().MaxIdle)
+
+	//invalid config is reported, and the pool is kept unchanged
+	assert.NoError(t, os.WriteFile(path, []byte("maxSize: 5\nminIdle: 4\n"), 0644))
+	assert.True(t, waitUntil(time.Second, func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(errs) > 0
+	}))
+	lock.Lock()
+	assert.IsType(t, &ConfigError{}, errs[0])
+	lock.Unlock()
+	assert.Equal(t, 0, p.Stats().MinIdle)
+
+	//deleted file is reported once, and applied when created again
+	lock.Lock()
+	reported := len(errs) + 1
+	lock.Unlock()
+	assert.NoError(t, os.Remove(path))
+	assert.True(t, waitUntil(time.Second, func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(errs) == reported && os.IsNotExist(errs[len(errs)-1])
+	}))
+	time.Sleep(time.Millisecond * 20)
+	assert.NoError(t, os.WriteFile(path, []byte("maxSize: 6\n"), 0644))
+	assert.True(t, waitUntil(time.Second, func() bool {
+		return p.Stats().MaxSize == 6
+	}))
+	lock.Lock()
+	assert.Equal(t, reported, len(errs))
+	lock.Unlock()
+
+	_, err = p.WatchConfigFile(filepath.Join(t.TempDir(), "none.yaml"), time.Millisecond, nil)
+	assert.Error(t, err)
+}
+
+func TestConfigWatcherStoppedWhenPoolClosed(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "pool.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte("maxSize: 2\n"), 0644))
+
+	cfg := NewConfig(testObjectCreateFactory)
+	cfg.AutoEvict = false
+	p, _ := New(cfg)
+	w, err := p.WatchConfigFile(path, time.Millisecond, nil)
+	assert.NoError(t, err)
+
+	//exits without the file changed
+	assert.NoError(t, p.Close(ctx))
+	select {
+	case <-w.done:
+	case <-time.After(time.Second):
+		t.Fatal("watcher is still running after pool closed")
+	}
+	w.Stop()
+}
+
+//waitUntil poll the condition every millisecond, and report whether it's satisfied before timeout
+func waitUntil(timeout time.Duration, condition func() bool) bool {
+	deadline := time.Now().Add(timeout)
+	for !condition() {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return true
+}
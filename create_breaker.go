@@ -0,0 +1,124 @@
+package pond
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+//CreateCircuitOpenError is returned when creating is rejected by the open circuit.
+//It matches ErrCreateCircuitOpen by errors.Is, and unwraps to the last create error.
+type CreateCircuitOpenError struct {
+	LastErr error
+}
+
+func (e *CreateCircuitOpenError) Error() string {
+	return fmt.Sprintf("%v: %v", ErrCreateCircuitOpen, e.LastErr)
+}
+
+func (e *CreateCircuitOpenError) Is(target error) bool {
+	return target == ErrCreateCircuitOpen
+}
+
+func (e *CreateCircuitOpenError) Unwrap() error {
+	return e.LastErr
+}
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+//createBreaker is a circuit breaker of creating objects, it is thread-safe.
+//It opens after threshold consecutive failures, and rejects creating until openDuration passed.
+//Then it's half-open, and up to probes creating are allowed. It's closed if all of them succeed, or open again if any fails.
+type createBreaker struct {
+	threshold    int
+	openDuration time.Duration
+	probes       int
+
+	lock      sync.Mutex
+	state     breakerState
+	failures  int //consecutive failures when closed
+	openAt    time.Time
+	probing   int //probes creating when half-open
+	succeeded int //probes succeeded when half-open
+	lastErr   error
+	now       func() time.Time
+}
+
+func newCreateBreaker(threshold int, openDuration time.Duration, probes int) *createBreaker {
+	return &createBreaker{
+		threshold:    threshold,
+		openDuration: openDuration,
+		probes:       probes,
+		now:          time.Now,
+	}
+}
+
+//Allow report whether creating is allowed, and whether it's a probe of half-open circuit
+func (b *createBreaker) Allow() (bool, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.state == breakerOpen && b.now().Sub(b.openAt) >= b.openDuration {
+		b.state = breakerHalfOpen
+		b.probing, b.succeeded = 0, 0
+	}
+	switch b.state {
+	case breakerOpen:
+		return false, &CreateCircuitOpenError{LastErr: b.lastErr}
+	case breakerHalfOpen:
+		if b.probing+b.succeeded >= b.probes {
+			return false, &CreateCircuitOpenError{LastErr: b.lastErr}
+		}
+		b.probing++
+		return true, nil
+	}
+	return false, nil
+}
+
+//Done record the result of creating allowed
+func (b *createBreaker) Done(probe bool, err error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if err != nil {
+		b.lastErr = err
+	}
+	if probe {
+		b.probing--
+		if b.state != breakerHalfOpen {
+			return
+		}
+		if err != nil {
+			b.open()
+			return
+		}
+		b.succeeded++
+		if b.succeeded >= b.probes {
+			b.state = breakerClosed
+			b.failures = 0
+		}
+		return
+	}
+	//the creating started before opened is not counted
+	if b.state != breakerClosed {
+		return
+	}
+	if err == nil {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold {
+		b.open()
+	}
+}
+
+func (b *createBreaker) open() {
+	b.state = breakerOpen
+	b.openAt = b.now()
+	b.failures = 0
+}
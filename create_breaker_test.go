@@ -0,0 +1,114 @@
+package pond
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateBreaker(t *testing.T) {
+	now := time.Now()
+	b := newCreateBreaker(2, time.Second, 2)
+	b.now = func() time.Time {
+		return now
+	}
+	errDial := errors.New("dial failed")
+
+	//consecutive failures open the circuit
+	for i := 0; i < 3; i++ {
+		probe, err := b.Allow()
+		assert.False(t, probe)
+		assert.NoError(t, err)
+		if i == 1 {
+			b.Done(probe, nil)
+			continue
+		}
+		b.Done(probe, errDial)
+	}
+	probe, _ := b.Allow()
+	b.Done(probe, errDial)
+	_, err := b.Allow()
+	assert.True(t, errors.Is(err, ErrCreateCircuitOpen))
+	assert.True(t, errors.Is(err, errDial))
+	assert.Equal(t, "circuit of creating objects is open: dial failed", err.Error())
+
+	//half-open allows probes only
+	now = now.Add(time.Second)
+	p1, err := b.Allow()
+	assert.True(t, p1)
+	assert.NoError(t, err)
+	p2, _ := b.Allow()
+	assert.True(t, p2)
+	_, err = b.Allow()
+	assert.True(t, errors.Is(err, ErrCreateCircuitOpen))
+
+	//a failed probe opens it again
+	b.Done(p1, nil)
+	b.Done(p2, errDial)
+	_, err = b.Allow()
+	assert.True(t, errors.Is(err, ErrCreateCircuitOpen))
+
+	//closed after all probes succeed
+	now = now.Add(time.Second)
+	for i := 0; i < 2; i++ {
+		probe, err := b.Allow()
+		assert.True(t, probe)
+		assert.NoError(t, err)
+		b.Done(probe, nil)
+	}
+	probe, err = b.Allow()
+	assert.False(t, probe)
+	assert.NoError(t, err)
+}
+
+func TestPoolCreateCircuitBreaker(t *testing.T) {
+	ctx := context.Background()
+	errDial := errors.New("dial failed")
+	var calls, down int32
+	cfg := NewConfig(func(ctx context.Context) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		if atomic.LoadInt32(&down) == 1 {
+			return nil, errDial
+		}
+		return &testObject{}, nil
+	})
+	cfg.AutoEvict = false
+	cfg.CreateBreakerThreshold = 3
+	cfg.CreateBreakerOpenDuration = time.Millisecond * 50
+	p, _ := New(cfg)
+	active, err := p.BorrowObject(ctx)
+	assert.NoError(t, err)
+	atomic.StoreInt32(&down, 1)
+
+	for i := 0; i < 3; i++ {
+		_, err := p.BorrowObject(ctx)
+		assert.Equal(t, errDial, err)
+	}
+	//fail fast without calling the factory
+	atomic.StoreInt32(&calls, 0)
+	_, err = p.BorrowObject(ctx)
+	assert.True(t, errors.Is(err, ErrCreateCircuitOpen))
+	assert.True(t, errors.Is(err, errDial))
+	assert.Equal(t, int32(0), atomic.LoadInt32(&calls))
+	assert.Equal(t, int64(3), p.Stats().CreateFailures)
+	assert.Equal(t, 1, p.Size())
+
+	//idle objects are still borrowed
+	assert.NoError(t, p.ReturnObject(ctx, active))
+	obj, err := p.BorrowObject(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, active, obj)
+
+	//probe after open duration
+	time.Sleep(time.Millisecond * 50)
+	atomic.StoreInt32(&down, 0)
+	_, err = p.BorrowObject(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	_, err = p.BorrowObject(ctx)
+	assert.NoError(t, err)
+}
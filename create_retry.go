@@ -0,0 +1,43 @@
+package pond
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+//CreateRetryPolicy is the policy of retrying ObjectCreateFactory when borrowing
+type CreateRetryPolicy struct {
+	//MaxAttempts is the maximal attempts of creating, including the first one. If MaxAttempts <= 1, no retry.
+	MaxAttempts int
+	//InitialBackoff is the backoff before the first retry
+	InitialBackoff time.Duration
+	//MaxBackoff is the maximal backoff between retries. If MaxBackoff <= 0, no limit.
+	MaxBackoff time.Duration
+	//Multiplier is the factor the backoff multiplied by after each retry
+	Multiplier float64
+	//Jitter is the maximal fraction of backoff randomly subtracted, in [0, 1]
+	Jitter float64
+	//Retryable classify the create errors. If nil, all errors are retryable.
+	Retryable func(err error) bool
+}
+
+//retryable report whether the create error should be retried
+func (r CreateRetryPolicy) retryable(err error) bool {
+	return r.Retryable == nil || r.Retryable(err)
+}
+
+//backoff return the jittered backoff before the nth retry, starting from 1
+func (r CreateRetryPolicy) backoff(n int) time.Duration {
+	backoff := float64(r.InitialBackoff) * math.Pow(r.Multiplier, float64(n-1))
+	if r.MaxBackoff > 0 && backoff > float64(r.MaxBackoff) {
+		backoff = float64(r.MaxBackoff)
+	}
+	if backoff >= math.MaxInt64 {
+		return time.Duration(math.MaxInt64)
+	}
+	if r.Jitter > 0 {
+		backoff -= backoff * r.Jitter * rand.Float64()
+	}
+	return time.Duration(backoff)
+}
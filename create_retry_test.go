@@ -0,0 +1,159 @@
+package pond
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateRetryPolicyBackoff(t *testing.T) {
+	r := CreateRetryPolicy{
+		InitialBackoff: time.Millisecond * 100,
+		MaxBackoff:     time.Second,
+		Multiplier:     2,
+	}
+	assert.Equal(t, time.Millisecond*100, r.backoff(1))
+	assert.Equal(t, time.Millisecond*200, r.backoff(2))
+	assert.Equal(t, time.Millisecond*800, r.backoff(4))
+	assert.Equal(t, time.Second, r.backoff(5))
+	r.MaxBackoff = 0
+	assert.Equal(t, time.Duration(1<<63-1), r.backoff(100))
+
+	r.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		backoff := r.backoff(1)
+		assert.True(t, backoff > time.Millisecond*50 && backoff <= time.Millisecond*100, "backoff %v", backoff)
+	}
+}
+
+func TestPoolCreateRetry(t *testing.T) {
+	ctx := context.Background()
+	errDial := errors.New("dial failed")
+	errAuth := errors.New("auth failed")
+	var calls, failures int32
+	var failure atomic.Value
+	failure.Store(errDial)
+	cfg := NewConfig(func(ctx context.Context) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		if atomic.AddInt32(&failures, -1) >= 0 {
+			return nil, failure.Load().(error)
+		}
+		return &testObject{}, nil
+	})
+	cfg.AutoEvict = false
+	cfg.CreateRetryPolicy = CreateRetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond * 10,
+		Multiplier:     2,
+		Retryable: func(err error) bool {
+			return err == errDial
+		},
+	}
+	p, _ := New(cfg)
+
+	//the transient errors are absorbed
+	atomic.StoreInt32(&failures, 2)
+	obj, err := p.BorrowObject(ctx)
+	assert.NoError(t, err)
+	assert.NotNil(t, obj)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	assert.Equal(t, int64(2), p.Stats().CreateFailures)
+
+	//up to MaxAttempts
+	atomic.StoreInt32(&calls, 0)
+	atomic.StoreInt32(&failures, 3)
+	_, err = p.BorrowObject(ctx)
+	assert.Equal(t, errDial, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+
+	//the error not retryable is returned directly
+	atomic.StoreInt32(&calls, 0)
+	atomic.StoreInt32(&failures, 1)
+	failure.Store(errAuth)
+	_, err = p.BorrowObject(ctx)
+	assert.Equal(t, errAuth, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	//stop retrying before the deadline of ctx
+	failure.Store(errDial)
+	atomic.StoreInt32(&calls, 0)
+	atomic.StoreInt32(&failures, 3)
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Millisecond*25)
+	defer cancel()
+	_, err = p.BorrowObject(timeoutCtx)
+	assert.Equal(t, errDial, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	assert.Equal(t, 1, p.Size())
+}
+
+func TestPoolCreateRetryThrottled(t *testing.T) {
+	ctx := context.Background()
+	errDial := errors.New("dial failed")
+	var calls, failures int32
+	cfg := NewConfig(func(ctx context.Context) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		if atomic.AddInt32(&failures, -1) >= 0 {
+			return nil, errDial
+		}
+		return &testObject{}, nil
+	})
+	cfg.AutoEvict = false
+	cfg.CreateRate = 10
+	cfg.CreateRetryPolicy = CreateRetryPolicy{
+		MaxAttempts:    15,
+		InitialBackoff: time.Millisecond,
+		Multiplier:     1,
+	}
+	p, _ := New(cfg)
+
+	//each attempt takes a token, so the retries beyond the burst are limited by rate
+	atomic.StoreInt32(&failures, 15)
+	begin := time.Now()
+	_, err := p.BorrowObject(ctx)
+	elapsed := time.Since(begin)
+	assert.Equal(t, errDial, err)
+	assert.Equal(t, int32(15), atomic.LoadInt32(&calls))
+	assert.True(t, elapsed >= time.Millisecond*400, "elapsed %v", elapsed)
+	assert.Equal(t, 0, p.Size())
+}
+
+func TestPoolCreateRetryReleasePermit(t *testing.T) {
+	ctx := context.Background()
+	errDial := errors.New("dial failed")
+	var calls int32
+	cfg := NewConfig(func(ctx context.Context) (interface{}, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			return nil, errDial
+		}
+		return &testObject{}, nil
+	})
+	cfg.AutoEvict = false
+	cfg.MaxConcurrentCreates = 1
+	cfg.CreateRetryPolicy = CreateRetryPolicy{
+		MaxAttempts:    2,
+		InitialBackoff: time.Millisecond * 200,
+		Multiplier:     1,
+	}
+	p, _ := New(cfg)
+
+	retried := make(chan error, 1)
+	go func() {
+		_, err := p.BorrowObject(ctx)
+		retried <- err
+	}()
+	assert.True(t, waitUntil(time.Second, func() bool { return atomic.LoadInt32(&calls) == 1 }))
+
+	//the permit of creating is not held while backing off
+	begin := time.Now()
+	obj, err := p.BorrowObject(ctx, WithNonblocking())
+	assert.NoError(t, err)
+	assert.NotNil(t, obj)
+	assert.True(t, time.Since(begin) < time.Millisecond*100)
+	assert.NoError(t, <-retried)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	assert.Equal(t, 2, p.Size())
+}
@@ -0,0 +1,58 @@
+package pond
+
+import (
+	"math"
+	"time"
+)
+
+//createThrottle limits the concurrency and rate of creating objects, it is not thread-safe.
+//The rate is limited by a token bucket, which is refilled by rate per second and holds up to one second of tokens.
+type createThrottle struct {
+	maxConcurrent int     //if maxConcurrent <= 0, no concurrency limit
+	rate          float64 //if rate <= 0, no rate limit
+	burst         float64
+	creating      int
+	tokens        float64
+	refillAt      time.Time
+	now           func() time.Time
+}
+
+func newCreateThrottle(maxConcurrent int, rate float64) *createThrottle {
+	burst := math.Max(1, math.Ceil(rate))
+	return &createThrottle{
+		maxConcurrent: maxConcurrent,
+		rate:          rate,
+		burst:         burst,
+		tokens:        burst,
+		refillAt:      time.Now(),
+		now:           time.Now,
+	}
+}
+
+//Acquire take a permit of creating. If it's denied by rate, it also reports how long to wait for the next token.
+//Or the delay is zero, and the permit should be waited until a creating done.
+func (t *createThrottle) Acquire() (bool, time.Duration) {
+	if t.maxConcurrent > 0 && t.creating >= t.maxConcurrent {
+		return false, 0
+	}
+	if t.rate > 0 {
+		t.refill()
+		if t.tokens < 1 {
+			return false, time.Duration(math.Ceil((1 - t.tokens) / t.rate * float64(time.Second)))
+		}
+		t.tokens--
+	}
+	t.creating++
+	return true, 0
+}
+
+//Release release the permit when the creating done
+func (t *createThrottle) Release() {
+	t.creating--
+}
+
+func (t *createThrottle) refill() {
+	now := t.now()
+	t.tokens = math.Min(t.burst, t.tokens+now.Sub(t.refillAt).Seconds()*t.rate)
+	t.refillAt = now
+}
@@ -0,0 +1,136 @@
+package pond
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateThrottle(t *testing.T) {
+	now := time.Now()
+	th := newCreateThrottle(2, 2)
+	th.now = func() time.Time {
+		return now
+	}
+	th.refillAt = now
+
+	//limited by concurrency
+	for i := 0; i < 2; i++ {
+		ok, _ := th.Acquire()
+		assert.True(t, ok)
+	}
+	ok, delay := th.Acquire()
+	assert.False(t, ok)
+	assert.Equal(t, time.Duration(0), delay)
+
+	//limited by rate
+	th.Release()
+	ok, delay = th.Acquire()
+	assert.False(t, ok)
+	assert.Equal(t, time.Millisecond*500, delay)
+	now = now.Add(time.Millisecond * 250)
+	_, delay = th.Acquire()
+	assert.Equal(t, time.Millisecond*250, delay)
+	now = now.Add(time.Millisecond * 250)
+	ok, _ = th.Acquire()
+	assert.True(t, ok)
+
+	//the bucket holds up to one second of tokens
+	th.Release()
+	th.Release()
+	now = now.Add(time.Minute)
+	for i := 0; i < 2; i++ {
+		ok, _ := th.Acquire()
+		assert.True(t, ok)
+		th.Release()
+	}
+	ok, delay = th.Acquire()
+	assert.False(t, ok)
+	assert.Equal(t, time.Millisecond*500, delay)
+}
+
+func TestPoolMaxConcurrentCreates(t *testing.T) {
+	ctx := context.Background()
+	gate := make(chan struct{})
+	var blocking, creating, maxCreating int32
+	cfg := NewConfig(func(ctx context.Context) (interface{}, error) {
+		n := atomic.AddInt32(&creating, 1)
+		defer atomic.AddInt32(&creating, -1)
+		if n > atomic.LoadInt32(&maxCreating) {
+			atomic.StoreInt32(&maxCreating, n)
+		}
+		if atomic.LoadInt32(&blocking) == 1 {
+			<-gate
+		}
+		return &testObject{}, nil
+	})
+	cfg.AutoEvict = false
+	cfg.MaxConcurrentCreates = 1
+	p, _ := New(cfg)
+	active, err := p.BorrowObject(ctx)
+	assert.NoError(t, err)
+
+	atomic.StoreInt32(&blocking, 1)
+	created := make(chan interface{}, 1)
+	go func() {
+		obj, _ := p.BorrowObject(ctx)
+		created <- obj
+	}()
+	assert.True(t, waitUntil(time.Second, func() bool { return atomic.LoadInt32(&creating) == 1 }))
+
+	//the borrower beyond the limit waits for the returned object
+	returned := make(chan interface{}, 1)
+	go func() {
+		obj, _ := p.BorrowObject(ctx)
+		returned <- obj
+	}()
+	assert.True(t, waitUntil(time.Second, func() bool { return p.Stats().Waiters == 1 }))
+	_, err = p.BorrowObject(ctx, WithNonblocking())
+	assert.Equal(t, ErrPoolExhausted, err)
+	assert.NoError(t, p.ReturnObject(ctx, active))
+	assert.Equal(t, active, <-returned)
+
+	//or the new object
+	go func() {
+		obj, _ := p.BorrowObject(ctx)
+		returned <- obj
+	}()
+	assert.True(t, waitUntil(time.Second, func() bool { return p.Stats().Waiters == 1 }))
+	close(gate)
+	assert.NotNil(t, <-created)
+	assert.NotNil(t, <-returned)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&maxCreating))
+	assert.Equal(t, 3, p.Size())
+}
+
+func TestPoolCreateRate(t *testing.T) {
+	ctx := context.Background()
+	cfg := NewConfig(func(ctx context.Context) (interface{}, error) {
+		return &testObject{}, nil
+	})
+	cfg.AutoEvict = false
+	cfg.MaxSize = 10
+	cfg.CreateRate = 5
+	p, _ := New(cfg)
+
+	//a burst of one second
+	for i := 0; i < 5; i++ {
+		_, err := p.BorrowObject(ctx)
+		assert.NoError(t, err)
+	}
+	_, err := p.BorrowObject(ctx, WithNonblocking())
+	assert.Equal(t, ErrPoolExhausted, err)
+
+	begin := time.Now()
+	for i := 0; i < 2; i++ {
+		_, err := p.BorrowObject(ctx)
+		assert.NoError(t, err)
+	}
+	elapsed := time.Since(begin)
+	assert.True(t, elapsed >= time.Millisecond*300, "elapsed %v", elapsed)
+	assert.Equal(t, 7, p.Size())
+	assert.Equal(t, int64(7), p.Stats().Creates)
+}
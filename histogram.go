@@ -0,0 +1,188 @@
+package pond
+
+import (
+	"math"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+//latencyBuckets are the upper bounds of latency histogram buckets. The last bucket is unbounded.
+var latencyBuckets = []time.Duration{
+	time.Microsecond,
+	time.Microsecond * 5,
+	time.Microsecond * 10,
+	time.Microsecond * 50,
+	time.Microsecond * 100,
+	time.Microsecond * 250,
+	time.Microsecond * 500,
+	time.Millisecond,
+	time.Millisecond * 2,
+	time.Millisecond * 5,
+	time.Millisecond * 10,
+	time.Millisecond * 25,
+	time.Millisecond * 50,
+	time.Millisecond * 100,
+	time.Millisecond * 250,
+	time.Millisecond * 500,
+	time.Second,
+	time.Second * 2,
+	time.Second * 5,
+	time.Second * 10,
+	time.Second * 30,
+	time.Minute,
+}
+
+//HistogramBucket is a bucket of histogram
+type HistogramBucket struct {
+	//UpperBound is the inclusive upper bound of bucket. The last bucket is math.MaxInt64.
+	UpperBound time.Duration
+	//Count is the number of observations in this bucket, not cumulative
+	Count int64
+}
+
+//Histogram is a snapshot of latency histogram
+type Histogram struct {
+	Buckets []HistogramBucket
+	Count   int64
+	Sum     time.Duration
+}
+
+//Mean return the average latency
+func (h Histogram) Mean() time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	return h.Sum / time.Duration(h.Count)
+}
+
+//Percentile estimate the q-th (0 <= q <= 1) percentile latency by interpolating within bucket
+func (h Histogram) Percentile(q float64) time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	if q < 0 {
+		q = 0
+	}
+	if q > 1 {
+		q = 1
+	}
+	rank := q * float64(h.Count)
+	var cumulative int64
+	var lower time.Duration
+	for _, b := range h.Buckets {
+		if b.Count > 0 && float64(cumulative+b.Count) >= rank {
+			if b.UpperBound == math.MaxInt64 {
+				//unbounded bucket
+				return lower
+			}
+			fraction := (rank - float64(cumulative)) / float64(b.Count)
+			return lower + time.Duration(fraction*float64(b.UpperBound-lower))
+		}
+		cumulative += b.Count
+		lower = b.UpperBound
+	}
+	return lower
+}
+
+//PoolHistograms are the latency histograms of pool
+type PoolHistograms struct {
+	//BorrowWait is the duration that borrower blocked for waiting objects
+	BorrowWait Histogram
+	//Create is the duration of ObjectCreateFactory
+	Create Histogram
+	//Validate is the duration of ObjectValidateFactory
+	Validate Histogram
+	//Destroy is the duration of ObjectDestroyFactory
+	Destroy Histogram
+}
+
+//latencyHistogram is a fixed-bucket histogram updated atomically
+type latencyHistogram struct {
+	counts []int64 //counts[i] is the count of latencyBuckets[i], and the last one is unbounded
+	sum    int64
+}
+
+func newLatencyHistogram() *latencyHistogram {
+	return &latencyHistogram{
+		counts: make([]int64, len(latencyBuckets)+1),
+	}
+}
+
+func (h *latencyHistogram) Observe(d time.Duration) {
+	i := sort.Search(len(latencyBuckets), func(i int) bool {
+		return d <= latencyBuckets[i]
+	})
+	atomic.AddInt64(&h.counts[i], 1)
+	atomic.AddInt64(&h.sum, int64(d))
+}
+
+//Since observe the duration since begin
+func (h *latencyHistogram) Since(begin time.Time) {
+	h.Observe(time.Since(begin))
+}
+
+func (h *latencyHistogram) Snapshot() Histogram {
+	snapshot := Histogram{
+		Buckets: make([]HistogramBucket, len(h.counts)),
+		Sum:     time.Duration(atomic.LoadInt64(&h.sum)),
+	}
+	for i := range h.counts {
+		bucket := HistogramBucket{
+			UpperBound: math.MaxInt64,
+			Count:      atomic.LoadInt64(&h.counts[i]),
+		}
+		if i < len(latencyBuckets) {
+			bucket.UpperBound = latencyBuckets[i]
+		}
+		snapshot.Buckets[i] = bucket
+		snapshot.Count += bucket.Count
+	}
+	return snapshot
+}
+
+type poolHistograms struct {
+	borrowWait *latencyHistogram
+	create     *latencyHistogram
+	validate   *latencyHistogram
+	destroy    *latencyHistogram
+}
+
+func newPoolHistograms() poolHistograms {
+	return poolHistograms{
+		borrowWait: newLatencyHistogram(),
+		create:     newLatencyHistogram(),
+		validate:   newLatencyHistogram(),
+		destroy:    newLatencyHistogram(),
+	}
+}
+
+func (h poolHistograms) Snapshot() PoolHistograms {
+	return PoolHistograms{
+		BorrowWait: h.borrowWait.Snapshot(),
+		Create:     h.create.Snapshot(),
+		Validate:   h.validate.Snapshot(),
+		Destroy:    h.destroy.Snapshot(),
+	}
+}
+
+//merge accumulate the observations of other histogram with the same buckets
+func (h *Histogram) merge(o Histogram) {
+	if h.Buckets == nil {
+		h.Buckets = make([]HistogramBucket, len(o.Buckets))
+		copy(h.Buckets, o.Buckets)
+	} else {
+		for i := range o.Buckets {
+			h.Buckets[i].Count += o.Buckets[i].Count
+		}
+	}
+	h.Count += o.Count
+	h.Sum += o.Sum
+}
+
+func (h *PoolHistograms) merge(o PoolHistograms) {
+	h.BorrowWait.merge(o.BorrowWait)
+	h.Create.merge(o.Create)
+	h.Validate.merge(o.Validate)
+	h.Destroy.merge(o.Destroy)
+}
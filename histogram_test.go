@@ -0,0 +1,91 @@
+package pond
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLatencyHistogram(t *testing.T) {
+	h := newLatencyHistogram()
+	assert.Equal(t, time.Duration(0), h.Snapshot().Percentile(0.99))
+	assert.Equal(t, time.Duration(0), h.Snapshot().Mean())
+
+	//90 fast and 10 slow observations
+	for i := 0; i < 90; i++ {
+		h.Observe(time.Millisecond)
+	}
+	for i := 0; i < 10; i++ {
+		h.Observe(time.Millisecond * 80)
+	}
+	snapshot := h.Snapshot()
+	assert.Equal(t, int64(100), snapshot.Count)
+	assert.Equal(t, time.Millisecond*890, snapshot.Sum)
+	assert.Equal(t, time.Microsecond*8900, snapshot.Mean())
+	assert.Equal(t, len(latencyBuckets)+1, len(snapshot.Buckets))
+	for _, b := range snapshot.Buckets {
+		switch b.UpperBound {
+		case time.Millisecond:
+			assert.Equal(t, int64(90), b.Count)
+		case time.Millisecond * 100:
+			assert.Equal(t, int64(10), b.Count)
+		default:
+			assert.Equal(t, int64(0), b.Count)
+		}
+	}
+	assert.True(t, snapshot.Percentile(0.5) <= time.Millisecond)
+	assert.True(t, snapshot.Percentile(0.9) <= time.Millisecond)
+	p99 := snapshot.Percentile(0.99)
+	assert.True(t, p99 > time.Millisecond*50 && p99 <= time.Millisecond*100)
+	assert.Equal(t, time.Millisecond*100, snapshot.Percentile(1))
+
+	//unbounded bucket
+	h.Observe(time.Hour)
+	snapshot = h.Snapshot()
+	assert.Equal(t, time.Duration(math.MaxInt64), snapshot.Buckets[len(latencyBuckets)].UpperBound)
+	assert.Equal(t, int64(1), snapshot.Buckets[len(latencyBuckets)].Count)
+	assert.Equal(t, latencyBuckets[len(latencyBuckets)-1], snapshot.Percentile(1))
+}
+
+func TestPoolHistograms(t *testing.T) {
+	ctx := context.Background()
+	delay := time.Millisecond * 20
+	cfg := NewConfig(func(ctx context.Context) (interface{}, error) {
+		time.Sleep(delay)
+		return &testObject{}, nil
+	})
+	cfg.MaxSize = 1
+	cfg.ObjectValidateFactory = func(ctx context.Context, object interface{}) bool {
+		return true
+	}
+	p, _ := New(cfg)
+	defer p.Close(ctx)
+
+	obj, err := p.BorrowObject(ctx)
+	assert.NoError(t, err)
+	go func() {
+		time.Sleep(delay)
+		p.ReturnObject(ctx, obj)
+	}()
+	obj, err = p.BorrowObject(ctx)
+	assert.NoError(t, err)
+	assert.NoError(t, p.InvalidateObject(ctx, obj))
+
+	histograms := p.Histograms()
+	assert.Equal(t, int64(1), histograms.Create.Count)
+	assert.True(t, histograms.Create.Sum >= delay)
+	assert.Equal(t, int64(1), histograms.BorrowWait.Count)
+	assert.True(t, histograms.BorrowWait.Percentile(0.5) >= delay/2)
+	assert.Equal(t, int64(2), histograms.Validate.Count)
+	assert.Equal(t, int64(1), histograms.Destroy.Count)
+
+	//the default validate factory is not timed
+	p, _ = New(NewConfig(testObjectCreateFactory))
+	defer p.Close(ctx)
+	_, err = p.BorrowObject(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), p.Histograms().Validate.Count)
+}
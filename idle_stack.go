@@ -0,0 +1,104 @@
+package pond
+
+import (
+	"sync/atomic"
+)
+
+//idleStack is a LIFO stack of idle objects, which could be pushed and popped concurrently without lock.
+//Objects are linked by ids rather than pointers, so that the head is tagged with a version against the ABA problem.
+//Objects must be registered before pushed, and registering is not thread-safe.
+type idleStack[T any] struct {
+	head  uint64       //id of the top object in low 32 bits, version in high 32 bits. The id 0 means empty.
+	len   int64        //increased before pushed and decreased after popped, so it's never less than the real length
+	table atomic.Value //[]*pooledObject[T] indexed by id, copied on write so that popping never reads a reused slot
+	free  []uint32     //ids unregistered
+}
+
+func newIdleStack[T any]() *idleStack[T] {
+	s := &idleStack[T]{}
+	//the id 0 is reserved for empty
+	s.table.Store(make([]*pooledObject[T], 1))
+	return s
+}
+
+//Register assign an id to the object
+func (s *idleStack[T]) Register(po *pooledObject[T]) {
+	old := s.table.Load().([]*pooledObject[T])
+	var id uint32
+	if n := len(s.free); n > 0 {
+		id = s.free[n-1]
+		s.free = s.free[:n-1]
+	} else {
+		id = uint32(len(old))
+	}
+	size := len(old)
+	if int(id) >= size {
+		size = int(id) + 1
+	}
+	table := make([]*pooledObject[T], size)
+	copy(table, old)
+	table[id] = po
+	po.id = id
+	s.table.Store(table)
+}
+
+//Unregister release the id of object, the object must not be in stack
+func (s *idleStack[T]) Unregister(po *pooledObject[T]) {
+	old := s.table.Load().([]*pooledObject[T])
+	if po.id == 0 || int(po.id) >= len(old) || old[po.id] != po {
+		return
+	}
+	table := make([]*pooledObject[T], len(old))
+	copy(table, old)
+	table[po.id] = nil
+	s.table.Store(table)
+	s.free = append(s.free, po.id)
+	po.id = 0
+}
+
+func (s *idleStack[T]) Len() int {
+	n := atomic.LoadInt64(&s.len)
+	if n < 0 {
+		return 0
+	}
+	return int(n)
+}
+
+func (s *idleStack[T]) Push(po *pooledObject[T]) {
+	atomic.AddInt64(&s.len, 1)
+	for {
+		head := atomic.LoadUint64(&s.head)
+		atomic.StoreUint32(&po.next, uint32(head))
+		if atomic.CompareAndSwapUint64(&s.head, head, packHead(po.id, head)) {
+			return
+		}
+	}
+}
+
+//Pop pop the latest pushed object, nil if empty
+func (s *idleStack[T]) Pop() *pooledObject[T] {
+	for {
+		head := atomic.LoadUint64(&s.head)
+		id := uint32(head)
+		if id == 0 {
+			return nil
+		}
+		//the table is loaded after the head, so it has the object pushed
+		table := s.table.Load().([]*pooledObject[T])
+		if int(id) >= len(table) || table[id] == nil {
+			//popped and unregistered by others, the head must have been changed
+			continue
+		}
+		po := table[id]
+		next := atomic.LoadUint32(&po.next)
+		if atomic.CompareAndSwapUint64(&s.head, head, packHead(next, head)) {
+			atomic.AddInt64(&s.len, -1)
+			return po
+		}
+	}
+}
+
+//packHead tag the id with the next version of head
+func packHead(id uint32, head uint64) uint64 {
+	return (head>>32+1)<<32 | uint64(id)
+}
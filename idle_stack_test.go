@@ -0,0 +1,76 @@
+package pond
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestIdleStack(t *testing.T) {
+	stk := newIdleStack[interface{}]()
+	size := 100
+	assert.Equal(t, 0, stk.Len())
+	assert.Nil(t, stk.Pop())
+
+	pos := make([]*pooledObject[interface{}], size)
+	for i := 0; i < size; i++ {
+		pos[i] = newPooledObject[interface{}](&testObject{name: strconv.Itoa(i)})
+		stk.Register(pos[i])
+		stk.Push(pos[i])
+	}
+	assert.Equal(t, size, stk.Len())
+	for i := size - 1; i >= 0; i-- {
+		pop := stk.Pop()
+		assert.Equal(t, pos[i], pop)
+		assert.Equal(t, i, stk.Len())
+	}
+	assert.Nil(t, stk.Pop())
+
+	//the ids are reused after unregistered
+	id := pos[0].id
+	stk.Unregister(pos[0])
+	assert.Equal(t, uint32(0), pos[0].id)
+	po := newPooledObject[interface{}](&testObject{name: "reused"})
+	stk.Register(po)
+	assert.Equal(t, id, po.id)
+	stk.Push(po)
+	assert.Equal(t, po, stk.Pop())
+}
+
+func TestIdleStackConcurrent(t *testing.T) {
+	stk := newIdleStack[interface{}]()
+	size := 16
+	for i := 0; i < size; i++ {
+		po := newPooledObject[interface{}](&testObject{name: strconv.Itoa(i)})
+		stk.Register(po)
+		stk.Push(po)
+	}
+
+	//every object is owned by one goroutine at most
+	var owners sync.Map
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				po := stk.Pop()
+				if po == nil {
+					continue
+				}
+				_, owned := owners.LoadOrStore(po, struct{}{})
+				assert.False(t, owned)
+				owners.Delete(po)
+				stk.Push(po)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, size, stk.Len())
+	popped := make(map[*pooledObject[interface{}]]struct{})
+	for po := stk.Pop(); po != nil; po = stk.Pop() {
+		popped[po] = struct{}{}
+	}
+	assert.Equal(t, size, len(popped))
+}
@@ -0,0 +1,75 @@
+package pond
+
+import (
+	"fmt"
+	"strings"
+)
+
+//IdleOrder is the order of borrowing idle objects
+type IdleOrder int
+
+const (
+	//IdleOrderLIFO borrow the latest returned object, idle objects exceeding MaxIdle are evicted soon
+	IdleOrderLIFO IdleOrder = iota
+	//IdleOrderFIFO borrow the earliest returned object, so that all objects are used round-robin
+	IdleOrderFIFO
+)
+
+func (o IdleOrder) String() string {
+	switch o {
+	case IdleOrderLIFO:
+		return "lifo"
+	case IdleOrderFIFO:
+		return "fifo"
+	default:
+		return fmt.Sprintf("IdleOrder(%d)", int(o))
+	}
+}
+
+func (o IdleOrder) MarshalText() ([]byte, error) {
+	return []byte(o.String()), nil
+}
+
+//UnmarshalText parse "lifo" or "fifo", case-insensitive
+func (o *IdleOrder) UnmarshalText(text []byte) error {
+	switch strings.ToLower(string(text)) {
+	case "lifo":
+		*o = IdleOrderLIFO
+	case "fifo":
+		*o = IdleOrderFIFO
+	default:
+		return fmt.Errorf("unknown idle order %q, lifo or fifo expected", text)
+	}
+	return nil
+}
+
+//idleStore stores idle objects, it is not thread-safe
+type idleStore[T any] interface {
+	Len() int
+	Push(po *pooledObject[T])
+	//Pop pop the next object to borrow
+	Pop() *pooledObject[T]
+	//Top return the next object to borrow
+	Top() *pooledObject[T]
+	//BPush push the object as the earliest returned one
+	BPush(po *pooledObject[T])
+	//BPop pop the earliest returned object
+	BPop() *pooledObject[T]
+	//Restore push the popped object back by the order returned
+	Restore(po *pooledObject[T])
+	//Bottom return the earliest returned object
+	Bottom() *pooledObject[T]
+	//Range iterate from the earliest returned object
+	Range(handler func(object *pooledObject[T]))
+	//RemoveIf remove all objects matched
+	RemoveIf(match func(object *pooledObject[T]) bool) []*pooledObject[T]
+}
+
+func newIdleStore[T any](order IdleOrder) idleStore[T] {
+	switch order {
+	case IdleOrderFIFO:
+		return newPooledQueue[T]()
+	default:
+		return newPooledStack[T]()
+	}
+}
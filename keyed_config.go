@@ -0,0 +1,51 @@
+package pond
+
+import (
+	"time"
+)
+
+const (
+	DefaultMaxTotal = 0
+)
+
+type TypedKeyConfigFactory[K comparable, T any] func(key K) TypedConfig[T]
+
+type KeyConfigFactory = TypedKeyConfigFactory[string, interface{}]
+
+//KeyedConfig is the config of KeyedPool
+type KeyedConfig = TypedKeyedConfig[string, interface{}]
+
+//TypedKeyedConfig is the config of TypedKeyedPool
+type TypedKeyedConfig[K comparable, T any] struct {
+	/**
+	The capacity of all keys. If MaxTotal <= 0, no capacity limit.
+	When it's reached, the earliest idle object of other keys will be evicted for the borrower.
+	*/
+	MaxTotal int
+	/**
+	Enable auto evict idle objects of all keys, and keys without any object.
+	When true, pool will create a goroutine to start a evictor. The AutoEvict of key config is ignored.
+	*/
+	AutoEvict bool
+	/**
+	The interval between evict.
+	*/
+	EvictInterval time.Duration
+	/**
+	The factory of key config, including the ObjectCreateFactory and the limits of key.
+	*/
+	KeyConfigFactory TypedKeyConfigFactory[K, T]
+}
+
+func NewKeyedConfig(keyConfigFactory KeyConfigFactory) KeyedConfig {
+	return NewTypedKeyedConfig(keyConfigFactory)
+}
+
+func NewTypedKeyedConfig[K comparable, T any](keyConfigFactory TypedKeyConfigFactory[K, T]) TypedKeyedConfig[K, T] {
+	return TypedKeyedConfig[K, T]{
+		MaxTotal:         DefaultMaxTotal,
+		AutoEvict:        DefaultAutoEvict,
+		EvictInterval:    DefaultEvictInterval,
+		KeyConfigFactory: keyConfigFactory,
+	}
+}
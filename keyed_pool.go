@@ -0,0 +1,330 @@
+package pond
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	ErrKeyConfigFactoryNotFound = errors.New("the factory of key config not found")
+)
+
+//KeyedPool is a thread-safe pool of interface{} objects by string key. It's a thin wrapper of TypedKeyedPool[string, interface{}].
+type KeyedPool struct {
+	*TypedKeyedPool[string, interface{}]
+}
+
+//TypedKeyedPool is a thread-safe pool of T objects by K key.
+//Each key has its own pool created by KeyConfigFactory, and all of them share the MaxTotal.
+type TypedKeyedPool[K comparable, T any] struct {
+	config TypedKeyedConfig[K, T]
+	budget *budget
+	lock   sync.RWMutex //lock for pools
+	pools  map[K]*TypedPool[T]
+	closed bool
+
+	evictorTicker *time.Ticker
+	evictorStop   chan struct{}
+	evictorGroup  sync.WaitGroup
+}
+
+//NewKeyed create a keyed pool by config
+func NewKeyed(config KeyedConfig) (*KeyedPool, error) {
+	k, err := NewTypedKeyed(config)
+	if err != nil {
+		return nil, err
+	}
+	return &KeyedPool{TypedKeyedPool: k}, nil
+}
+
+//NewTypedKeyed create a typed keyed pool by config
+func NewTypedKeyed[K comparable, T any](config TypedKeyedConfig[K, T]) (*TypedKeyedPool[K, T], error) {
+	if config.KeyConfigFactory == nil {
+		return nil, ErrKeyConfigFactoryNotFound
+	}
+	k := &TypedKeyedPool[K, T]{
+		config: config,
+		budget: newBudget(config.MaxTotal),
+		pools:  make(map[K]*TypedPool[T]),
+	}
+	k.budget.reclaim = k.reclaim
+	if config.AutoEvict {
+		k.evictorTicker = time.NewTicker(config.EvictInterval)
+		k.evictorStop = make(chan struct{})
+		k.evictorGroup.Add(1)
+		go func() {
+			defer k.evictorGroup.Done()
+			k.StartEvictor()
+		}()
+	}
+	return k, nil
+}
+
+//BorrowObject promise to return a idle object of key. It will be blocked when there is no any idle object.
+func (k *KeyedPool) BorrowObject(ctx context.Context, key string, opts ...BorrowOption) (interface{}, error) {
+	return k.Borrow(ctx, key, opts...)
+}
+
+//ReturnObject return the borrowed object of key to pool
+func (k *KeyedPool) ReturnObject(ctx context.Context, key string, object interface{}) error {
+	return k.Return(ctx, key, object)
+}
+
+//InvalidateObject delete and destroy the active object of key
+func (k *KeyedPool) InvalidateObject(ctx context.Context, key string, object interface{}) error {
+	return k.Invalidate(ctx, key, object)
+}
+
+//Borrow promise to return a idle object of key. It will be blocked when there is no any idle object.
+func (k *TypedKeyedPool[K, T]) Borrow(ctx context.Context, key K, opts ...BorrowOption) (T, error) {
+	var object T
+	err := k.withPool(key, func(p *TypedPool[T]) (err error) {
+		object, err = p.Borrow(ctx, opts...)
+		return err
+	})
+	return object, err
+}
+
+//BorrowHandle borrow a object of key owned by the returned lease
+func (k *TypedKeyedPool[K, T]) BorrowHandle(ctx context.Context, key K, opts ...BorrowOption) (*Lease[T], error) {
+	var lease *Lease[T]
+	err := k.withPool(key, func(p *TypedPool[T]) (err error) {
+		lease, err = p.BorrowHandle(ctx, opts...)
+		return err
+	})
+	return lease, err
+}
+
+//Return return the borrowed object of key to pool
+func (k *TypedKeyedPool[K, T]) Return(ctx context.Context, key K, object T) error {
+	p := k.lookup(key)
+	if p == nil {
+		//return a object that not existed
+		return nil
+	}
+	return p.Return(ctx, object)
+}
+
+//Invalidate delete and destroy the active object of key
+func (k *TypedKeyedPool[K, T]) Invalidate(ctx context.Context, key K, object T) error {
+	p := k.lookup(key)
+	if p == nil {
+		return nil
+	}
+	return p.Invalidate(ctx, object)
+}
+
+//Stats return a snapshot of statistics of key
+func (k *TypedKeyedPool[K, T]) Stats(key K) (PoolStats, bool) {
+	p := k.lookup(key)
+	if p == nil {
+		return PoolStats{}, false
+	}
+	return p.Stats(), true
+}
+
+//Keys return the keys having a pool
+func (k *TypedKeyedPool[K, T]) Keys() []K {
+	k.lock.RLock()
+	defer k.lock.RUnlock()
+	keys := make([]K, 0, len(k.pools))
+	for key := range k.pools {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+//Size return the number of objects of all keys
+func (k *TypedKeyedPool[K, T]) Size() int {
+	size := 0
+	for _, p := range k.snapshot() {
+		size += p.Size()
+	}
+	return size
+}
+
+//withPool call fn with the pool of key. If the pool has been evicted, retry with a new one.
+func (k *TypedKeyedPool[K, T]) withPool(key K, fn func(p *TypedPool[T]) error) error {
+	for {
+		p, err := k.pool(key)
+		if err != nil {
+			return err
+		}
+		err = fn(p)
+		if err != ErrPoolClosed {
+			return err
+		}
+
+		k.lock.Lock()
+		closed := k.closed
+		if !closed && k.pools[key] == p {
+			delete(k.pools, key)
+		}
+		k.lock.Unlock()
+		if closed {
+			return ErrPoolClosed
+		}
+	}
+}
+
+func (k *TypedKeyedPool[K, T]) lookup(key K) *TypedPool[T] {
+	k.lock.RLock()
+	defer k.lock.RUnlock()
+	return k.pools[key]
+}
+
+//pool return the pool of key, create it if not existed
+func (k *TypedKeyedPool[K, T]) pool(key K) (*TypedPool[T], error) {
+	if p := k.lookup(key); p != nil {
+		return p, nil
+	}
+
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	if k.closed {
+		return nil, ErrPoolClosed
+	}
+	if p, ok := k.pools[key]; ok {
+		return p, nil
+	}
+	config := k.config.KeyConfigFactory(key)
+	//all keys are evicted by keyed pool
+	config.AutoEvict = false
+	if config.Name == "" {
+		config.Name = fmt.Sprint(key)
+	}
+	p, err := newTypedPool(config, k.budget)
+	if err != nil {
+		return nil, err
+	}
+	k.pools[key] = p
+	return p, nil
+}
+
+func (k *TypedKeyedPool[K, T]) snapshot() []*TypedPool[T] {
+	k.lock.RLock()
+	defer k.lock.RUnlock()
+	pools := make([]*TypedPool[T], 0, len(k.pools))
+	for _, p := range k.pools {
+		pools = append(pools, p)
+	}
+	return pools
+}
+
+//reclaim evict the earliest idle object of all keys, so that its slot could be used by other keys
+func (k *TypedKeyedPool[K, T]) reclaim(ctx context.Context) bool {
+	type candidate struct {
+		pool     *TypedPool[T]
+		idleTime time.Duration
+	}
+	var candidates []candidate
+	for _, p := range k.snapshot() {
+		if idleTime, ok := p.earliestIdleTime(); ok {
+			candidates = append(candidates, candidate{pool: p, idleTime: idleTime})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].idleTime > candidates[j].idleTime
+	})
+	for _, c := range candidates {
+		if c.pool.evictEarliest(ctx) {
+			return true
+		}
+	}
+	return false
+}
+
+//Evict evict idle objects of all keys, and remove the keys without any object
+func (k *TypedKeyedPool[K, T]) Evict(ctx context.Context) error {
+	k.lock.RLock()
+	closed := k.closed
+	pools := make(map[K]*TypedPool[T], len(k.pools))
+	for key, p := range k.pools {
+		pools[key] = p
+	}
+	k.lock.RUnlock()
+	if closed {
+		return ErrPoolClosed
+	}
+
+	var evictErr error
+	for key, p := range pools {
+		if err := p.Evict(ctx); err != nil && err != ErrPoolClosed && evictErr == nil {
+			evictErr = err
+		}
+		if !p.closeIfUnused(ctx) {
+			continue
+		}
+		k.lock.Lock()
+		if k.pools[key] == p {
+			delete(k.pools, key)
+		}
+		k.lock.Unlock()
+	}
+	return evictErr
+}
+
+func (k *TypedKeyedPool[K, T]) StartEvictor() {
+	for {
+		select {
+		case <-k.evictorTicker.C:
+			_ = k.Evict(context.Background())
+		case <-k.evictorStop:
+			return
+		}
+	}
+}
+
+//Close close the pools of all keys
+func (k *TypedKeyedPool[K, T]) Close(ctx context.Context) error {
+	pools, err := k.close()
+	if err != nil {
+		return err
+	}
+	for _, p := range pools {
+		_ = p.Close(ctx)
+	}
+	return nil
+}
+
+//Shutdown shutdown the pools of all keys, and report the number of objects destroyed forcibly
+func (k *TypedKeyedPool[K, T]) Shutdown(ctx context.Context) (int, error) {
+	pools, _ := k.close()
+	if pools == nil {
+		pools = k.snapshot()
+	}
+	defer k.evictorGroup.Wait()
+
+	forced := 0
+	var shutdownErr error
+	for _, p := range pools {
+		n, err := p.Shutdown(ctx)
+		forced += n
+		if err != nil {
+			shutdownErr = err
+		}
+	}
+	return forced, shutdownErr
+}
+
+func (k *TypedKeyedPool[K, T]) close() ([]*TypedPool[T], error) {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	if k.closed {
+		return nil, ErrPoolClosed
+	}
+	k.closed = true
+	if k.evictorTicker != nil {
+		k.evictorTicker.Stop()
+		close(k.evictorStop)
+	}
+	pools := make([]*TypedPool[T], 0, len(k.pools))
+	for _, p := range k.pools {
+		pools = append(pools, p)
+	}
+	return pools, nil
+}
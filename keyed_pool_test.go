@@ -0,0 +1,227 @@
+package pond
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestKeyedConfig(maxSize int) KeyedConfig {
+	cfg := NewKeyedConfig(func(key string) Config {
+		cfg := NewConfig(func(ctx context.Context) (interface{}, error) {
+			return &testObject{name: key}, nil
+		})
+		cfg.MaxSize = maxSize
+		cfg.MinIdleTime = 0
+		return cfg
+	})
+	cfg.AutoEvict = false
+	return cfg
+}
+
+func TestKeyedPool(t *testing.T) {
+	ctx := context.Background()
+	p, err := NewKeyed(newTestKeyedConfig(2))
+	assert.NoError(t, err)
+	defer p.Close(ctx)
+
+	a, err := p.BorrowObject(ctx, "a")
+	assert.NoError(t, err)
+	assert.Equal(t, "a", a.(*testObject).name)
+	b, err := p.BorrowObject(ctx, "b")
+	assert.NoError(t, err)
+	assert.Equal(t, "b", b.(*testObject).name)
+	assert.NoError(t, p.ReturnObject(ctx, "a", a))
+	assert.NoError(t, p.InvalidateObject(ctx, "b", b))
+
+	keys := p.Keys()
+	sort.Strings(keys)
+	assert.Equal(t, []string{"a", "b"}, keys)
+	assert.Equal(t, 1, p.Size())
+
+	stats, ok := p.Stats("a")
+	assert.True(t, ok)
+	assert.Equal(t, int64(1), stats.Borrows)
+	assert.Equal(t, 1, stats.Idle)
+	assert.Equal(t, 2, stats.MaxSize)
+	stats, ok = p.Stats("b")
+	assert.True(t, ok)
+	assert.Equal(t, int64(1), stats.Invalidations)
+	_, ok = p.Stats("c")
+	assert.False(t, ok)
+
+	//reuse idle object
+	obj, err := p.BorrowObject(ctx, "a")
+	assert.NoError(t, err)
+	assert.Equal(t, a, obj)
+	assert.NoError(t, p.ReturnObject(ctx, "a", obj))
+
+	_, err = NewKeyed(KeyedConfig{})
+	assert.Equal(t, ErrKeyConfigFactoryNotFound, err)
+}
+
+func TestKeyedPoolMaxSize(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestKeyedConfig(1)
+	p, _ := NewKeyed(cfg)
+	defer p.Close(ctx)
+
+	a, err := p.BorrowObject(ctx, "a")
+	assert.NoError(t, err)
+	//other keys are not limited
+	b, err := p.BorrowObject(ctx, "b")
+	assert.NoError(t, err)
+
+	cctx, cancel := context.WithTimeout(ctx, time.Millisecond*10)
+	defer cancel()
+	_, err = p.BorrowObject(cctx, "a")
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	assert.NoError(t, p.ReturnObject(ctx, "a", a))
+	assert.NoError(t, p.ReturnObject(ctx, "b", b))
+}
+
+func TestKeyedPoolMaxTotal(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestKeyedConfig(2)
+	cfg.MaxTotal = 2
+	p, _ := NewKeyed(cfg)
+	defer p.Close(ctx)
+
+	a1, _ := p.BorrowObject(ctx, "a")
+	a2, _ := p.BorrowObject(ctx, "a")
+
+	//wait for other keys
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		b, err := p.BorrowObject(ctx, "b")
+		assert.NoError(t, err)
+		assert.Equal(t, "b", b.(*testObject).name)
+		assert.NoError(t, p.ReturnObject(ctx, "b", b))
+	}()
+	time.Sleep(time.Millisecond * 10)
+	stats, _ := p.Stats("b")
+	assert.Equal(t, 1, stats.Waiters)
+	assert.NoError(t, p.InvalidateObject(ctx, "a", a1))
+	wg.Wait()
+	assert.Equal(t, 2, p.Size())
+
+	//reclaim idle objects of other keys
+	assert.NoError(t, p.ReturnObject(ctx, "a", a2))
+	c, err := p.BorrowObject(ctx, "c")
+	assert.NoError(t, err)
+	assert.Equal(t, "c", c.(*testObject).name)
+	d, err := p.BorrowObject(ctx, "d")
+	assert.NoError(t, err)
+	assert.Equal(t, "d", d.(*testObject).name)
+	assert.Equal(t, 2, p.Size())
+	assert.Equal(t, 2, p.budget.Used())
+	stats, _ = p.Stats("a")
+	assert.Equal(t, int64(1), stats.Evictions)
+
+	//all slots are active
+	cctx, cancel := context.WithTimeout(ctx, time.Millisecond*10)
+	defer cancel()
+	_, err = p.BorrowObject(cctx, "e")
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.NoError(t, p.ReturnObject(ctx, "c", c))
+	assert.NoError(t, p.ReturnObject(ctx, "d", d))
+}
+
+func TestKeyedPoolEvict(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestKeyedConfig(2)
+	cfg.MaxTotal = 4
+	p, _ := NewKeyed(cfg)
+	defer p.Close(ctx)
+
+	a, _ := p.BorrowObject(ctx, "a")
+	b, _ := p.BorrowObject(ctx, "b")
+	assert.NoError(t, p.ReturnObject(ctx, "a", a))
+	assert.NoError(t, p.Evict(ctx))
+	//a is idle, b is active
+	assert.Equal(t, 2, len(p.Keys()))
+
+	for _, key := range p.Keys() {
+		pool := p.lookup(key)
+		pool.actionLock.Lock()
+		pool.config.MaxIdle = 0
+		pool.actionLock.Unlock()
+	}
+	assert.NoError(t, p.Evict(ctx))
+	//the objects of a are evicted, so a is removed
+	assert.Equal(t, []string{"b"}, p.Keys())
+	assert.Equal(t, 1, p.budget.Used())
+
+	//borrow again after evicted
+	a, err := p.BorrowObject(ctx, "a")
+	assert.NoError(t, err)
+	assert.NoError(t, p.ReturnObject(ctx, "a", a))
+	assert.NoError(t, p.ReturnObject(ctx, "b", b))
+}
+
+func TestKeyedPoolEvictedConcurrently(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestKeyedConfig(0)
+	cfg.MaxTotal = 4
+	p, _ := NewKeyed(cfg)
+	defer p.Close(ctx)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < loopSize; j++ {
+				obj, err := p.BorrowObject(ctx, "a")
+				assert.NoError(t, err)
+				assert.NoError(t, p.InvalidateObject(ctx, "a", obj))
+			}
+		}()
+	}
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				_ = p.Evict(ctx)
+			}
+		}
+	}()
+	wg.Wait()
+	close(done)
+	assert.Equal(t, 0, p.Size())
+	assert.Equal(t, 0, p.budget.Used())
+}
+
+func TestKeyedPoolShutdown(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestKeyedConfig(2)
+	cfg.AutoEvict = true
+	cfg.EvictInterval = time.Millisecond
+	p, _ := NewKeyed(cfg)
+
+	a, _ := p.BorrowObject(ctx, "a")
+	b, _ := p.BorrowObject(ctx, "b")
+	assert.NoError(t, p.ReturnObject(ctx, "b", b))
+
+	cctx, cancel := context.WithTimeout(ctx, time.Millisecond*10)
+	defer cancel()
+	forced, err := p.Shutdown(cctx)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, 1, forced)
+	assert.NoError(t, p.ReturnObject(ctx, "a", a))
+	assert.Equal(t, 0, p.budget.Used())
+
+	_, err = p.BorrowObject(ctx, "a")
+	assert.Equal(t, ErrPoolClosed, err)
+	assert.Equal(t, ErrPoolClosed, p.Close(ctx))
+}
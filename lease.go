@@ -0,0 +1,52 @@
+package pond
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+//Lease owns a borrowed object until it's released or invalidated.
+//The object is tracked by the lease rather than its value, so any type of object could be pooled.
+type Lease[T any] struct {
+	pool     *TypedPool[T]
+	po       *pooledObject[T]
+	released int32
+}
+
+func newLease[T any](pool *TypedPool[T], po *pooledObject[T]) *Lease[T] {
+	return &Lease[T]{
+		pool: pool,
+		po:   po,
+	}
+}
+
+//Object return the borrowed object
+func (l *Lease[T]) Object() T {
+	return l.po.Object()
+}
+
+//Release return the object to pool. The lease can't be used after released.
+func (l *Lease[T]) Release(ctx context.Context) error {
+	if !atomic.CompareAndSwapInt32(&l.released, 0, 1) {
+		return ErrLeaseReleased
+	}
+	p := l.pool
+	valid := p.validateReturning(ctx, l.po.Object())
+	return p.releaseObject(ctx, l.po, valid)
+}
+
+//Invalidate delete and destroy the object. The lease can't be used after invalidated.
+func (l *Lease[T]) Invalidate(ctx context.Context) error {
+	if !atomic.CompareAndSwapInt32(&l.released, 0, 1) {
+		return ErrLeaseReleased
+	}
+	p := l.pool
+	p.actionLock.Lock()
+	retired := p.invalidateObject(l.po)
+	p.actionLock.Unlock()
+	if retired {
+		p.counters.add(&p.counters.invalidations, 1)
+		return p.destroyRetired(ctx, l.po)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package pond
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLease(t *testing.T) {
+	ctx := context.Background()
+	cfg := NewTypedConfig(func(ctx context.Context) ([]byte, error) {
+		return make([]byte, 8), nil
+	})
+	destroyed := 0
+	cfg.ObjectDestroyFactory = func(ctx context.Context, object []byte) error {
+		destroyed++
+		return nil
+	}
+	cfg.MaxSize = 2
+	p, _ := NewTyped(cfg)
+
+	l1, err := p.BorrowHandle(ctx)
+	assert.NoError(t, err)
+	l2, err := p.BorrowHandle(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 8, len(l1.Object()))
+	assert.Equal(t, 2, p.ActiveSize())
+
+	//release
+	assert.NoError(t, l1.Release(ctx))
+	assert.Equal(t, ErrLeaseReleased, l1.Release(ctx))
+	assert.Equal(t, ErrLeaseReleased, l1.Invalidate(ctx))
+	assert.Equal(t, 1, p.ActiveSize())
+	assert.Equal(t, 1, p.IdleSize())
+
+	//invalidate
+	assert.NoError(t, l2.Invalidate(ctx))
+	assert.Equal(t, ErrLeaseReleased, l2.Release(ctx))
+	assert.Equal(t, 1, destroyed)
+	assert.Equal(t, 0, p.ActiveSize())
+	assert.Equal(t, 1, p.IdleSize())
+
+	//borrow by value is not supported for non-comparable objects
+	_, err = p.Borrow(ctx)
+	assert.Equal(t, ErrObjectNotComparable, err)
+	assert.Equal(t, 0, p.ActiveSize())
+	assert.Equal(t, 1, p.IdleSize())
+
+	//release after closed
+	l3, err := p.BorrowHandle(ctx)
+	assert.NoError(t, err)
+	assert.NoError(t, p.Close(ctx))
+	assert.NoError(t, l3.Release(ctx))
+	assert.Equal(t, 2, destroyed)
+}
+
+func TestBorrowValueEqualObjects(t *testing.T) {
+	ctx := context.Background()
+	type value struct {
+		n int
+	}
+	cfg := NewTypedConfig(func(ctx context.Context) (value, error) {
+		return value{n: 1}, nil
+	})
+	p, _ := NewTyped(cfg)
+
+	objs := make([]value, 0)
+	for i := 0; i < p.config.MaxSize; i++ {
+		obj, err := p.Borrow(ctx)
+		assert.NoError(t, err)
+		objs = append(objs, obj)
+	}
+	assert.Equal(t, p.config.MaxSize, p.ActiveSize())
+	for _, obj := range objs {
+		assert.NoError(t, p.Return(ctx, obj))
+	}
+	assert.Equal(t, 0, p.ActiveSize())
+	assert.Equal(t, p.config.MaxSize, p.IdleSize())
+}
+
+func TestLeaseDynamicallyUnhashable(t *testing.T) {
+	ctx := context.Background()
+	type holder struct {
+		V interface{}
+	}
+	cfg := NewTypedConfig(func(ctx context.Context) (holder, error) {
+		return holder{V: []int{1}}, nil
+	})
+	cfg.MaxSize = 1
+	p, _ := NewTyped(cfg)
+
+	//the type is comparable, but the value held is not
+	l, err := p.BorrowHandle(ctx)
+	assert.NoError(t, err)
+	assert.NoError(t, l.Release(ctx))
+	_, err = p.Borrow(ctx)
+	assert.Equal(t, ErrObjectNotComparable, err)
+	assert.Equal(t, 0, p.ActiveSize())
+	assert.NoError(t, p.Return(ctx, holder{V: []int{1}}))
+	assert.NoError(t, p.Invalidate(ctx, holder{V: []int{1}}))
+
+	//the pool is still usable
+	l, err = p.BorrowHandle(ctx)
+	assert.NoError(t, err)
+	assert.NoError(t, l.Invalidate(ctx))
+	assert.NoError(t, p.Close(ctx))
+}
@@ -0,0 +1,46 @@
+package pond
+
+import (
+	"context"
+	"time"
+)
+
+//TypedPoolListener is notified of the lifecycle events of pool.
+//It's called without lock, so it's safe to call the pool in callbacks, but it should return quickly.
+type TypedPoolListener[T any] interface {
+	//OnCreate is called after ObjectCreateFactory, err is not nil if failed
+	OnCreate(ctx context.Context, object T, err error)
+	//OnBorrow is called after the object borrowed, waited is the duration blocked for waiting objects
+	OnBorrow(ctx context.Context, object T, waited time.Duration)
+	//OnReturn is called when the object accepted by Return, before it's made idle, handed to a waiter or destroyed,
+	//so that it's never notified after the object borrowed again
+	OnReturn(ctx context.Context, object T)
+	//OnValidateFail is called when the object failed to validate when borrowed, returned or idle
+	OnValidateFail(ctx context.Context, object T)
+	//OnEvict is called when the idle object evicted by evictor
+	OnEvict(ctx context.Context, object T)
+	//OnDestroy is called after ObjectDestroyFactory, err is the error returned by it
+	OnDestroy(ctx context.Context, object T, err error)
+	//OnExhausted is called when the borrow rejected by ErrPoolExhausted
+	OnExhausted(ctx context.Context)
+	//OnClose is called after the pool closed
+	OnClose(ctx context.Context)
+}
+
+//PoolListener is the listener of Pool
+type PoolListener = TypedPoolListener[interface{}]
+
+//TypedNopPoolListener ignores all events. Embed it to implement only the interested callbacks.
+type TypedNopPoolListener[T any] struct{}
+
+//NopPoolListener ignores all events of Pool
+type NopPoolListener = TypedNopPoolListener[interface{}]
+
+func (TypedNopPoolListener[T]) OnCreate(ctx context.Context, object T, err error)            {}
+func (TypedNopPoolListener[T]) OnBorrow(ctx context.Context, object T, waited time.Duration) {}
+func (TypedNopPoolListener[T]) OnReturn(ctx context.Context, object T)                       {}
+func (TypedNopPoolListener[T]) OnValidateFail(ctx context.Context, object T)                 {}
+func (TypedNopPoolListener[T]) OnEvict(ctx context.Context, object T)                        {}
+func (TypedNopPoolListener[T]) OnDestroy(ctx context.Context, object T, err error)           {}
+func (TypedNopPoolListener[T]) OnExhausted(ctx context.Context)                              {}
+func (TypedNopPoolListener[T]) OnClose(ctx context.Context)                                  {}
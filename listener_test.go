@@ -0,0 +1,136 @@
+package pond
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//testListener records the events of pool
+type testListener struct {
+	NopPoolListener
+	lock   sync.Mutex
+	events []string
+	waited time.Duration
+}
+
+func (l *testListener) record(event string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.events = append(l.events, event)
+}
+
+func (l *testListener) Events() []string {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	events := l.events
+	l.events = nil
+	return events
+}
+
+func (l *testListener) OnCreate(ctx context.Context, object interface{}, err error) {
+	if err != nil {
+		l.record("create failed")
+		return
+	}
+	l.record("create")
+}
+
+func (l *testListener) OnBorrow(ctx context.Context, object interface{}, waited time.Duration) {
+	l.lock.Lock()
+	l.waited = waited
+	l.lock.Unlock()
+	l.record("borrow")
+}
+
+func (l *testListener) OnReturn(ctx context.Context, object interface{}) {
+	l.record("return")
+}
+
+func (l *testListener) OnValidateFail(ctx context.Context, object interface{}) {
+	l.record("validate failed")
+}
+
+func (l *testListener) OnEvict(ctx context.Context, object interface{}) {
+	l.record("evict")
+}
+
+func (l *testListener) OnDestroy(ctx context.Context, object interface{}, err error) {
+	l.record("destroy")
+}
+
+func (l *testListener) OnExhausted(ctx context.Context) {
+	l.record("exhausted")
+}
+
+func (l *testListener) OnClose(ctx context.Context) {
+	l.record("close")
+}
+
+func TestPoolListener(t *testing.T) {
+	ctx := context.Background()
+	listener := &testListener{}
+	cfg := NewConfig(testObjectCreateFactory)
+	cfg.MaxSize = 1
+	cfg.MaxIdle = 0
+	cfg.MinIdleTime = 0
+	cfg.AutoEvict = false
+	cfg.ObjectValidateFactory = testObjectValidateFactory
+	cfg.Listener = listener
+	p, _ := New(cfg)
+
+	obj, err := p.BorrowObject(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"create", "borrow"}, listener.Events())
+
+	//waited
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		obj, err := p.BorrowObject(ctx)
+		assert.NoError(t, err)
+		assert.NoError(t, p.ReturnObject(ctx, obj))
+	}()
+	time.Sleep(time.Millisecond * 10)
+	assert.NoError(t, p.ReturnObject(ctx, obj))
+	<-done
+	assert.Equal(t, []string{"return", "borrow", "return"}, listener.Events())
+	listener.lock.Lock()
+	assert.True(t, listener.waited >= time.Millisecond*10)
+	listener.lock.Unlock()
+
+	//evict
+	assert.NoError(t, p.Evict(ctx))
+	assert.Equal(t, []string{"evict", "destroy"}, listener.Events())
+
+	//create and validate failures
+	_, err = p.BorrowObject(context.WithValue(ctx, contextKeyCreateErr{}, errors.New("create error")))
+	assert.Error(t, err)
+	assert.Equal(t, []string{"create failed"}, listener.Events())
+	_, err = p.BorrowObject(context.WithValue(ctx, contextKeyValidateErr{}, "failed"))
+	assert.Equal(t, ErrObjectValidateFailed, err)
+	//MaxValidateAttempts is 1, so it's created twice
+	assert.Equal(t, []string{
+		"create", "validate failed", "destroy",
+		"create", "validate failed", "destroy",
+	}, listener.Events())
+
+	//exhausted
+	p.actionLock.Lock()
+	p.config.Nonblocking = true
+	p.actionLock.Unlock()
+	obj, err = p.BorrowObject(ctx)
+	assert.NoError(t, err)
+	_, err = p.BorrowObject(ctx)
+	assert.Equal(t, ErrPoolExhausted, err)
+	assert.Equal(t, []string{"create", "borrow", "exhausted"}, listener.Events())
+
+	assert.NoError(t, p.Close(ctx))
+	assert.Equal(t, []string{"close"}, listener.Events())
+	assert.NoError(t, p.ReturnObject(ctx, obj))
+	assert.Equal(t, []string{"return", "destroy"}, listener.Events())
+}
@@ -3,7 +3,10 @@ package pond
 import (
 	"context"
 	"errors"
+	"math/rand"
+	"runtime/debug"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -14,207 +17,1051 @@ var (
 	ErrObjectNotFound              = errors.New("object not found")
 	ErrObjectValidateFailed        = errors.New("object validate failed")
 	ErrObjectCreateFactoryNotFound = errors.New("the factory of object creating not found")
+	ErrObjectNotComparable         = errors.New("object is not comparable, borrow it by handle")
+	ErrLeaseReleased               = errors.New("lease has been released")
+	ErrBorrowTimeout               = errors.New("timeout waiting for object")
+	ErrNoIdleObject                = errors.New("no idle object")
+	ErrCreateCircuitOpen           = errors.New("circuit of creating objects is open")
 )
 
-//Pool is a thread-safe pool
+//Pool is a thread-safe pool of interface{} objects. It's a thin wrapper of TypedPool[interface{}].
 type Pool struct {
-	manager    *poolManager
-	config     Config
-	actionLock sync.RWMutex //lock for borrow/return/evict/... actions
-	wakeupCh   chan struct{}
+	*TypedPool[interface{}]
+}
+
+//TypedPool is a thread-safe pool of T objects.
+//If IdleOrder is LIFO, borrowing and returning idle objects don't need lock, only creating, waiting and evicting do.
+type TypedPool[T any] struct {
+	manager     *poolManager[T]
+	config      TypedConfig[T]
+	actionLock  sync.RWMutex //lock for borrow/return/evict/... actions
+	waiters     *waiterQueue[T]
+	reserved    int //slots reserved for creating objects
+	counters    poolCounters
+	histograms  poolHistograms
+	listener    TypedPoolListener[T]
+	budget      *budget              //capacity shared with other pools, nil if not shared
+	breaker     *createBreaker       //nil if CreateBreakerThreshold <= 0
+	throttle    *createThrottle      //nil if neither MaxConcurrentCreates nor CreateRate is set
+	refilling   *time.Timer          //dispatch slots to waiters when the throttle is refilled, nil if not scheduled
+	group       *TypedShardedPool[T] //the sharded pool it belongs to, nil if not sharded
+	maxUses     int64                //MaxUses of config, read without lock
+	nopValidate bool                 //ObjectValidateFactory is nil or the default one which always passes
+	overflowed  int32                //whether the pool exceeds MaxSize, read without lock
 
 	evictorTicker *time.Ticker
-	closed        bool
+	evictorStop   chan struct{}
+	evictorGroup  sync.WaitGroup
+	testedUntil   time.Time //return time of the last idle object tested in this round, zero if a new round
+	closed        int32
+	forced        bool          //active objects have been destroyed forcibly by Shutdown
+	drained       chan struct{} //closed when pool closed and all objects destroyed
+	isDrained     bool
 }
 
 //New create a pool by config
 func New(config Config) (*Pool, error) {
+	p, err := NewTyped(config)
+	if err != nil {
+		return nil, err
+	}
+	return &Pool{TypedPool: p}, nil
+}
+
+//NewTyped create a typed pool by config
+func NewTyped[T any](config TypedConfig[T]) (*TypedPool[T], error) {
+	return newTypedPool(config, nil)
+}
+
+//newTypedPool create a typed pool sharing the budget with other pools
+func newTypedPool[T any](config TypedConfig[T], budget *budget) (*TypedPool[T], error) {
 	if config.ObjectCreateFactory == nil {
 		return nil, ErrObjectCreateFactoryNotFound
 	}
-	p := &Pool{
-		manager:  newPoolManager(),
-		config:   config,
-		wakeupCh: make(chan struct{}, 1),
+	p := &TypedPool[T]{
+		manager:    newPoolManager[T](config.IdleOrder),
+		config:     config,
+		waiters:    newWaiterQueue[T](),
+		histograms: newPoolHistograms(),
+		listener:   config.Listener,
+		budget:     budget,
+		drained:    make(chan struct{}),
+
+		nopValidate: isNopValidateFactory(config.ObjectValidateFactory),
+	}
+	if p.listener == nil {
+		p.listener = TypedNopPoolListener[T]{}
 	}
+	if config.CreateBreakerThreshold > 0 {
+		p.breaker = newCreateBreaker(config.CreateBreakerThreshold, config.CreateBreakerOpenDuration, config.CreateBreakerHalfOpenProbes)
+	}
+	if config.MaxConcurrentCreates > 0 || config.CreateRate > 0 {
+		p.throttle = newCreateThrottle(config.MaxConcurrentCreates, config.CreateRate)
+	}
+	p.syncLimits()
 	if config.AutoEvict {
-		p.evictorTicker = time.NewTicker(p.config.EvictInterval)
-		go p.StartEvictor()
+		p.startEvictor()
 	}
 	return p, nil
 }
 
-func (p *Pool) isClosed() bool {
-	return p.closed
+func (p *TypedPool[T]) isClosed() bool {
+	return atomic.LoadInt32(&p.closed) == 1
 }
 
-func (p *Pool) isFull() bool {
+func (p *TypedPool[T]) isFull() bool {
 	if p.config.MaxSize <= 0 {
 		return false
 	}
-	return p.manager.Size() >= p.config.MaxSize
+	return p.manager.Size()+p.reserved >= p.config.MaxSize
 }
 
-func (p *Pool) ActiveSize() int {
-	p.actionLock.RLock()
-	defer p.actionLock.RUnlock()
+//isOverflowed report whether the pool exceeds MaxSize, e.g. after MaxSize shrunk
+func (p *TypedPool[T]) isOverflowed() bool {
+	if p.config.MaxSize <= 0 {
+		return false
+	}
+	return p.manager.Size()+p.reserved > p.config.MaxSize
+}
+
+//syncLimits publish the limits read by returning without lock. It must be called with lock.
+func (p *TypedPool[T]) syncLimits() {
+	atomic.StoreInt64(&p.maxUses, int64(p.config.MaxUses))
+	p.manager.StampBorrow(p.config.AbandonedTimeout > 0)
+	overflowed := int32(0)
+	if p.isOverflowed() {
+		overflowed = 1
+	}
+	atomic.StoreInt32(&p.overflowed, overflowed)
+}
+
+func (p *TypedPool[T]) ActiveSize() int {
 	return p.manager.ActiveSize()
 }
 
-func (p *Pool) IdleSize() int {
+func (p *TypedPool[T]) IdleSize() int {
 	p.actionLock.RLock()
 	defer p.actionLock.RUnlock()
 	return p.manager.IdleSize()
 }
 
-func (p *Pool) Size() int {
+func (p *TypedPool[T]) Size() int {
 	p.actionLock.RLock()
 	defer p.actionLock.RUnlock()
 	return p.manager.Size()
 }
 
-func (p *Pool) createObject(ctx context.Context) error {
-	if p.isFull() {
-		return ErrPoolFulled
+//Stats return a snapshot of pool statistics
+func (p *TypedPool[T]) Stats() PoolStats {
+	stats := PoolStats{}
+	p.counters.load(&stats)
+	p.actionLock.RLock()
+	defer p.actionLock.RUnlock()
+	stats.Waiters = p.waiters.Len()
+	stats.Active = p.manager.ActiveSize()
+	stats.Idle = p.manager.IdleSize()
+	stats.MaxSize = p.config.MaxSize
+	stats.MinIdle = p.config.MinIdle
+	stats.MaxIdle = p.config.MaxIdle
+	return stats
+}
+
+//Histograms return snapshots of latency histograms
+func (p *TypedPool[T]) Histograms() PoolHistograms {
+	return p.histograms.Snapshot()
+}
+
+//reserveSlot reserve a slot for creating object if pool is not full and creating is not throttled
+func (p *TypedPool[T]) reserveSlot() bool {
+	if p.isFull() || (p.budget != nil && !p.budget.Acquire(p)) {
+		return false
 	}
-	//create a new one
-	object, err := p.config.ObjectCreateFactory(ctx)
+	if !p.permitCreate() {
+		if p.budget != nil {
+			p.budget.Release()
+		}
+		return false
+	}
+	p.reserved++
+	return true
+}
+
+//permitCreate take a permit of creating from the throttle.
+//If it's denied by rate, the waiters will be dispatched when the throttle is refilled.
+func (p *TypedPool[T]) permitCreate() bool {
+	if p.throttle == nil {
+		return true
+	}
+	permitted, delay := p.throttle.Acquire()
+	if !permitted && delay > 0 && p.refilling == nil {
+		p.refilling = time.AfterFunc(delay, p.dispatchRefill)
+	}
+	return permitted
+}
+
+//dispatchRefill hand the slots permitted by the refilled throttle to waiters
+func (p *TypedPool[T]) dispatchRefill() {
+	p.actionLock.Lock()
+	p.refilling = nil
+	for p.dispatchSlot() {
+	}
+	p.actionLock.Unlock()
+}
+
+//createDone release the permit of creating, and hand it to the longest-waiting borrower
+func (p *TypedPool[T]) createDone() {
+	if p.throttle == nil {
+		return
+	}
+	p.throttle.Release()
+	p.dispatchSlot()
+}
+
+//releaseSlot release a slot reserved by reserveSlot without creating object
+func (p *TypedPool[T]) releaseSlot() {
+	if p.throttle != nil {
+		p.throttle.Release()
+	}
+	p.freeSlot()
+}
+
+//keepSlot keep the slot of retired object for creating, and report whether it's kept.
+//If creating is throttled, the slot is freed.
+func (p *TypedPool[T]) keepSlot() bool {
+	if p.permitCreate() {
+		return true
+	}
+	p.freeSlot()
+	return false
+}
+
+//freeSlot free a slot reserved without permit of creating, e.g. by retired object
+func (p *TypedPool[T]) freeSlot() {
+	p.reserved--
+	if p.budget != nil {
+		p.budget.Release()
+	}
+	p.dispatchSlot()
+	p.checkDrained()
+}
+
+//dispatchBudget hand the budget released by other pools to waiters
+func (p *TypedPool[T]) dispatchBudget() {
+	p.actionLock.Lock()
+	p.dispatchSlot()
+	p.actionLock.Unlock()
+}
+
+//checkDrained notify Shutdown if pool closed and there is no active or reserved object
+func (p *TypedPool[T]) checkDrained() {
+	if !p.isClosed() || p.isDrained || p.manager.ActiveSize() > 0 || p.reserved > 0 {
+		return
+	}
+	p.isDrained = true
+	close(p.drained)
+}
+
+//dispatchSlot hand the free slot to the longest-waiting borrower, and report whether it's handed
+func (p *TypedPool[T]) dispatchSlot() bool {
+	if p.isClosed() || p.waiters.Len() == 0 || !p.reserveSlot() {
+		return false
+	}
+	w := p.waiters.Dequeue()
+	w.ch <- waitResult[T]{slot: true}
+	return true
+}
+
+//dispatchObject hand the deactivated object to the longest-waiting borrower, or make it idle
+func (p *TypedPool[T]) dispatchObject(po *pooledObject[T]) {
+	if p.waiters.Len() == 0 {
+		p.manager.Release(po)
+		return
+	}
+	p.manager.Handoff(po)
+	w := p.waiters.Dequeue()
+	w.ch <- waitResult[T]{po: po}
+}
+
+//retireObject remove the object owned by caller, but its slot is reserved until it's destroyed by destroyRetired
+func (p *TypedPool[T]) retireObject(po *pooledObject[T]) {
+	p.manager.Deactivate(po)
+	p.manager.Remove(po)
+	p.reserved++
+}
+
+//destroyRetired destroy the retired object and release its slot. It must be called without lock.
+func (p *TypedPool[T]) destroyRetired(ctx context.Context, po *pooledObject[T]) error {
+	err := p.destroyObject(ctx, po.Object())
+	p.actionLock.Lock()
+	p.manager.Unregister(po)
+	p.freeSlot()
+	p.actionLock.Unlock()
+	return err
+}
+
+//createObject create a active object with the reserved slot. It must be called without lock.
+//If lend, the object is created for borrower, and the failed creating is retried by CreateRetryPolicy.
+func (p *TypedPool[T]) createObject(ctx context.Context, lend bool) (*pooledObject[T], error) {
+	object, err := p.tryCreate(ctx)
+	permitted := true
+	for attempt := 1; err != nil && lend; attempt++ {
+		backoff, retry := p.retryBackoff(ctx, attempt, err)
+		if !retry {
+			break
+		}
+		if p.throttle != nil {
+			//don't hold the permit of creating while backing off
+			p.actionLock.Lock()
+			p.createDone()
+			p.actionLock.Unlock()
+			permitted = false
+		}
+		if !p.waitRetry(ctx, backoff) {
+			break
+		}
+		permitted = true
+		object, err = p.tryCreate(ctx)
+	}
+	p.actionLock.Lock()
+	defer p.actionLock.Unlock()
 	if err != nil {
-		return err
+		if permitted {
+			p.releaseSlot()
+		} else {
+			p.freeSlot()
+		}
+		return nil, err
 	}
-	p.manager.Create(object)
-	return nil
+	p.reserved--
+	po := p.manager.Activate(object)
+	po.ExpireAfter(p.lifetime())
+	if lend {
+		p.manager.Borrowed(po)
+	}
+	p.createDone()
+	return po, nil
 }
 
-//BorrowObject promise to return a idle object. It will be blocked when there is no any idle object.
-func (p *Pool) BorrowObject(ctx context.Context) (interface{}, error) {
-	var object interface{}
-	validateCount := 0
-	for object == nil {
+//tryCreate create a object once, unless it's rejected by the circuit breaker
+func (p *TypedPool[T]) tryCreate(ctx context.Context) (T, error) {
+	probe := false
+	if p.breaker != nil {
+		var err error
+		if probe, err = p.breaker.Allow(); err != nil {
+			//fail fast without calling the factory
+			var zero T
+			return zero, err
+		}
+	}
+	begin := time.Now()
+	object, err := p.create(ctx)
+	p.histograms.create.Since(begin)
+	if p.breaker != nil {
+		p.breaker.Done(probe, err)
+	}
+	if err != nil {
+		p.counters.add(&p.counters.createFailures, 1)
+	} else {
+		p.counters.add(&p.counters.creates, 1)
+	}
+	p.listener.OnCreate(ctx, object, err)
+	return object, err
+}
+
+//retryBackoff return the backoff of the nth retry, and report whether the failed creating should be retried.
+//It's not retried if the error is not retryable, the pool is closed, or the ctx would be done before retrying.
+func (p *TypedPool[T]) retryBackoff(ctx context.Context, n int, err error) (time.Duration, bool) {
+	policy := p.config.CreateRetryPolicy
+	if n >= policy.MaxAttempts || errors.Is(err, ErrCreateCircuitOpen) || !policy.retryable(err) || p.isClosed() {
+		return 0, false
+	}
+	backoff := policy.backoff(n)
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= backoff {
+		return 0, false
+	}
+	return backoff, true
+}
+
+//waitRetry wait for the backoff, then a permit of creating from the throttle if any, so that retries are throttled too.
+//It reports whether the permit is taken, or false if the pool is closed or the ctx is done.
+func (p *TypedPool[T]) waitRetry(ctx context.Context, backoff time.Duration) bool {
+	for {
+		if !sleepContext(ctx, backoff) {
+			return false
+		}
 		p.actionLock.Lock()
 		if p.isClosed() {
 			p.actionLock.Unlock()
-			return nil, ErrPoolClosed
+			return false
 		}
-
-		var err error
-		object, err = p.borrowObject(ctx)
+		if p.throttle == nil {
+			p.actionLock.Unlock()
+			return true
+		}
+		permitted, delay := p.throttle.Acquire()
 		p.actionLock.Unlock()
-		if err == nil {
-			return object, nil
-		}
-
-		switch err {
-		case ErrObjectNotFound:
-			select {
-			//wakened or closed
-			case <-p.wakeupCh:
-			//wait for context canceled
-			case <-ctx.Done():
-				return nil, ctx.Err()
+		if permitted {
+			return true
+		}
+		//denied by rate, wait for the next token. Or poll until a creating done.
+		backoff = delay
+		if backoff <= 0 {
+			backoff = createPermitPollInterval
+		}
+	}
+}
+
+//createPermitPollInterval is the interval polling the permit of creating for retrying, when it's denied by concurrency
+const createPermitPollInterval = time.Millisecond * 10
+
+//sleepContext sleep for the duration, and report whether it's not interrupted by the done ctx
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+//create call ObjectCreateFactory in a span if Tracer configured
+func (p *TypedPool[T]) create(ctx context.Context) (T, error) {
+	if p.config.Tracer == nil {
+		return p.config.ObjectCreateFactory(ctx)
+	}
+	ctx, span := p.startSpan(ctx, SpanCreate)
+	object, err := p.config.ObjectCreateFactory(ctx)
+	endSpan(span, err)
+	return object, err
+}
+
+//lifetime return the jittered lifetime of new object.
+//The jitter is clamped to MaxLifetime, so that the lifetime is always positive even if the config is not validated.
+func (p *TypedPool[T]) lifetime() time.Duration {
+	lifetime := p.config.MaxLifetime
+	jitter := p.config.LifetimeJitter
+	if jitter > lifetime {
+		jitter = lifetime
+	}
+	if lifetime > 0 && jitter > 0 {
+		lifetime -= time.Duration(rand.Int63n(int64(jitter)))
+	}
+	return lifetime
+}
+
+//BorrowObject promise to return a idle object. It will be blocked when there is no any idle object.
+func (p *Pool) BorrowObject(ctx context.Context, opts ...BorrowOption) (interface{}, error) {
+	return p.Borrow(ctx, opts...)
+}
+
+//ReturnObject return the borrowed object to pool
+func (p *Pool) ReturnObject(ctx context.Context, object interface{}) error {
+	return p.Return(ctx, object)
+}
+
+//InvalidateObject delete and destroy the active object
+func (p *Pool) InvalidateObject(ctx context.Context, object interface{}) error {
+	return p.Invalidate(ctx, object)
+}
+
+//Borrow promise to return a idle object. It will be blocked when there is no any idle object.
+//The object is tracked by value, so it must be comparable. Use BorrowHandle for the others.
+func (p *TypedPool[T]) Borrow(ctx context.Context, opts ...BorrowOption) (T, error) {
+	var zero T
+	po, err := p.borrow(ctx, true, newBorrowOptions(opts))
+	if err != nil {
+		return zero, err
+	}
+	return po.Object(), nil
+}
+
+//BorrowHandle borrow a object owned by the returned lease. Any type of object could be borrowed by lease.
+func (p *TypedPool[T]) BorrowHandle(ctx context.Context, opts ...BorrowOption) (*Lease[T], error) {
+	po, err := p.borrow(ctx, false, newBorrowOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+	return newLease(p, po), nil
+}
+
+func (p *TypedPool[T]) borrow(ctx context.Context, track bool, o borrowOptions) (*pooledObject[T], error) {
+	var stack []byte
+	if p.config.AbandonedStackTrace {
+		stack = debug.Stack()
+	}
+	validateCount := 0
+	reserved := false
+	var waited time.Duration
+	for {
+		po, w, err := p.acquire(ctx, reserved, waited, o)
+		waited = w
+		if err != nil {
+			return nil, err
+		}
+
+		//validate object without lock
+		if !p.validateBorrowed(ctx, po) {
+			p.counters.add(&p.counters.validateFailures, 1)
+			p.listener.OnValidateFail(ctx, po.Object())
+			p.actionLock.Lock()
+			retired := p.invalidateObject(po)
+			p.actionLock.Unlock()
+			if retired {
+				_ = p.destroyObject(ctx, po.Object())
 			}
-		case ErrObjectValidateFailed:
+
 			validateCount++
-			if validateCount > p.config.MaxValidateAttempts {
+			exceeded := validateCount > p.config.MaxValidateAttempts
+			reserved = false
+			if retired {
+				p.actionLock.Lock()
+				p.manager.Unregister(po)
+				if exceeded {
+					p.freeSlot()
+				} else {
+					//keep the slot to create a new one when retrying
+					reserved = p.keepSlot()
+				}
+				p.actionLock.Unlock()
+			}
+			if exceeded {
 				return nil, ErrObjectValidateFailed
 			}
-		default:
-			return nil, err
+			continue
 		}
+		if stack != nil {
+			p.actionLock.Lock()
+			po.stack = stack
+			p.actionLock.Unlock()
+		}
+		if track {
+			if err := p.trackObject(ctx, po); err != nil {
+				return nil, err
+			}
+		}
+		p.borrowed(ctx, po, waited)
+		return po, nil
 	}
+}
 
-	return object, nil
+//borrowed count and notify the borrowed object
+func (p *TypedPool[T]) borrowed(ctx context.Context, po *pooledObject[T], waited time.Duration) {
+	p.counters.add(&p.counters.borrows, 1)
+	p.listener.OnBorrow(ctx, po.Object(), waited)
 }
 
-func (p *Pool) borrowObject(ctx context.Context) (interface{}, error) {
-	//if there is no idle objects
-	if p.manager.IdleSize() <= 0 {
-		if p.isFull() {
-			//if pool is exhausted, and NonBlocking enabled
-			if p.config.Nonblocking {
-				return nil, ErrPoolExhausted
+//acquire get a active object from idle objects, creating or waiting for returning.
+//If reserved, the caller has owned a slot for creating. It also reports the total duration waited since borrowing.
+func (p *TypedPool[T]) acquire(ctx context.Context, reserved bool, waited time.Duration, o borrowOptions) (*pooledObject[T], time.Duration, error) {
+	reclaimed := false
+	for {
+		//borrow a idle object without lock
+		if !reserved {
+			if po := p.manager.TryBorrow(); po != nil {
+				if !po.Expired() && !p.isClosed() {
+					return po, waited, nil
+				}
+				//skip the expired object, or destroy it if closed concurrently
+				p.actionLock.Lock()
+				p.retireObject(po)
+				p.actionLock.Unlock()
+				_ = p.destroyRetired(ctx, po)
+				continue
 			}
-		} else {
-			//if pool is not full, just create a new object
-			if err := p.createObject(ctx); err != nil {
-				return nil, err
+		}
+
+		p.actionLock.Lock()
+		if p.isClosed() {
+			if reserved {
+				p.releaseSlot()
+			}
+			p.checkDrained()
+			p.actionLock.Unlock()
+			return nil, waited, ErrPoolClosed
+		}
+
+		if po := p.manager.Borrow(); po != nil {
+			if po.Expired() {
+				//skip the expired object
+				p.retireObject(po)
+				p.actionLock.Unlock()
+				_ = p.destroyRetired(ctx, po)
+				continue
 			}
+			if reserved {
+				p.releaseSlot()
+			}
+			p.actionLock.Unlock()
+			return po, waited, nil
 		}
+
+		if o.noCreate {
+			if reserved {
+				p.releaseSlot()
+			}
+			p.actionLock.Unlock()
+			return nil, waited, ErrNoIdleObject
+		}
+		if !reserved {
+			reserved = p.reserveSlot()
+		}
+		if reserved {
+			p.actionLock.Unlock()
+			//if pool is not full and creating is not throttled, just create a new object
+			po, err := p.createObject(ctx, true)
+			return po, waited, err
+		}
+
+		//if pool is not full but the shared budget is exhausted, try to reclaim a idle object of other pools
+		if p.budget != nil && !reclaimed && !p.isFull() {
+			p.actionLock.Unlock()
+			reclaimed = true
+			p.budget.Reclaim(ctx)
+			continue
+		}
+
+		//if pool is exhausted or creating is throttled, and NonBlocking enabled
+		if p.config.Nonblocking || o.nonblocking {
+			p.actionLock.Unlock()
+			p.counters.add(&p.counters.exhausted, 1)
+			p.listener.OnExhausted(ctx)
+			return nil, waited, ErrPoolExhausted
+		}
+		maxWait := p.maxWait(ctx, o)
+		if maxWait > 0 {
+			if maxWait <= waited {
+				p.actionLock.Unlock()
+				p.counters.add(&p.counters.borrowTimeouts, 1)
+				return nil, waited, ErrBorrowTimeout
+			}
+			maxWait -= waited
+		}
+		w := p.waiters.Enqueue()
+		//the objects returned without lock before enqueued are not handed to waiters, so check them again
+		p.dispatchIdle()
+		p.actionLock.Unlock()
+
+		begin := time.Now()
+		res, err := p.tracedWait(ctx, w, maxWait)
+		waited += time.Since(begin)
+		if err != nil {
+			return nil, waited, err
+		}
+		if res.po != nil {
+			return res.po, waited, nil
+		}
+		reserved = res.slot
 	}
+}
 
-	po := p.manager.Borrow()
-	if po == nil {
-		return nil, ErrObjectNotFound
+//maxWait return the limit of waiting by options, or by config if ctx has no deadline. It must be called with lock.
+func (p *TypedPool[T]) maxWait(ctx context.Context, o borrowOptions) time.Duration {
+	if o.hasMaxWait {
+		return o.maxWait
+	}
+	if _, ok := ctx.Deadline(); ok {
+		return 0
 	}
+	return p.config.MaxWait
+}
+
+//wait until the waiter is waken, the context is canceled, or maxWait exceeded if maxWait is positive
+func (p *TypedPool[T]) wait(ctx context.Context, w *waiter[T], maxWait time.Duration) (waitResult[T], error) {
+	begin := time.Now()
+	p.counters.add(&p.counters.borrowWaits, 1)
+	defer func() {
+		waited := time.Since(begin)
+		p.counters.add(&p.counters.waitDuration, int64(waited))
+		p.histograms.borrowWait.Observe(waited)
+	}()
+
+	var timeout <-chan time.Time
+	if maxWait > 0 {
+		timer := time.NewTimer(maxWait)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	var err error
+	select {
+	case res := <-w.ch:
+		return res, nil
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-timeout:
+		err = ErrBorrowTimeout
+	}
+	p.counters.add(&p.counters.borrowTimeouts, 1)
 
-	object := po.Object()
-	//validate object
-	vFactory := p.config.ObjectValidateFactory
-	success := true
-	if vFactory != nil {
-		success = vFactory(ctx, object)
+	var retired *pooledObject[T]
+	p.actionLock.Lock()
+	if !p.waiters.Remove(w) {
+		//waken before removing, pass what it got to the next waiter
+		res := <-w.ch
+		if res.po != nil && p.returnObject(res.po) {
+			retired = res.po
+		} else if res.slot {
+			p.releaseSlot()
+		}
 	}
-	if !success {
-		_ = p.invalidateObject(ctx, object)
-		return nil, ErrObjectValidateFailed
+	p.actionLock.Unlock()
+	if retired != nil {
+		_ = p.destroyRetired(ctx, retired)
 	}
-	return object, nil
+	return waitResult[T]{}, err
 }
 
-//InvalidateObject delete and destroy the active object
-func (p *Pool) InvalidateObject(ctx context.Context, object interface{}) error {
+//tracedWait wait in a span if Tracer configured
+func (p *TypedPool[T]) tracedWait(ctx context.Context, w *waiter[T], maxWait time.Duration) (waitResult[T], error) {
+	if p.config.Tracer == nil {
+		return p.wait(ctx, w, maxWait)
+	}
+	ctx, span := p.startSpan(ctx, SpanWait)
+	res, err := p.wait(ctx, w, maxWait)
+	endSpan(span, err)
+	return res, err
+}
+
+//validateBorrowed validate the borrowed object, traced with whether it's fresh or reused.
+//The span is started even if validating is skipped, so that fresh and reused objects are always reported.
+func (p *TypedPool[T]) validateBorrowed(ctx context.Context, po *pooledObject[T]) bool {
+	if p.config.Tracer == nil {
+		return p.validateObject(ctx, po.Object())
+	}
+	ctx, span := p.startSpan(ctx, SpanValidate, Attribute{Key: AttrObjectFresh, Value: po.BorrowCount() == 1})
+	valid := p.validateObject(ctx, po.Object())
+	if !valid {
+		span.RecordError(ErrObjectValidateFailed)
+	}
+	span.End()
+	return valid
+}
+
+func (p *TypedPool[T]) validateObject(ctx context.Context, object T) bool {
+	if p.nopValidate {
+		return true
+	}
+	begin := time.Now()
+	valid := p.config.ObjectValidateFactory(ctx, object)
+	p.histograms.validate.Since(begin)
+	return valid
+}
+
+//validateReturning validate the returning object if ValidateOnReturn enabled
+func (p *TypedPool[T]) validateReturning(ctx context.Context, object T) bool {
+	return !p.config.ValidateOnReturn || p.validateObject(ctx, object)
+}
+
+//trackObject mark the object to be found by value. If it's not comparable, it will be returned.
+func (p *TypedPool[T]) trackObject(ctx context.Context, po *pooledObject[T]) error {
+	if isComparable(po.Object()) {
+		if !p.manager.Indexed(po) {
+			p.actionLock.Lock()
+			p.manager.Index(po)
+			p.actionLock.Unlock()
+		}
+		p.manager.Track(po)
+		return nil
+	}
+	//give it back, it can't be tracked by value
 	p.actionLock.Lock()
-	defer p.actionLock.Unlock()
-	err := p.invalidateObject(ctx, object)
-	if !p.isClosed() {
-		//shouldn't wakeup when pool closed
-		p.wakeup()
+	retired := p.returnObject(po)
+	p.actionLock.Unlock()
+	if retired {
+		_ = p.destroyRetired(ctx, po)
 	}
-	return err
+	return ErrObjectNotComparable
+}
+
+//lookupObject find the active object borrowed by value. It could be called without lock.
+func (p *TypedPool[T]) lookupObject(object T) *pooledObject[T] {
+	if !isComparable(object) {
+		return nil
+	}
+	return p.manager.Lookup(object)
 }
 
-func (p *Pool) invalidateObject(ctx context.Context, object interface{}) error {
-	p.manager.Deactivate(object)
+//Invalidate delete and destroy the active object
+func (p *TypedPool[T]) Invalidate(ctx context.Context, object T) error {
+	if found, err := p.invalidateTracked(ctx, object); found {
+		return err
+	}
+	//destroy the object even if it's not found
 	return p.destroyObject(ctx, object)
 }
 
-func (p *Pool) ReturnObject(ctx context.Context, object interface{}) error {
+//invalidateTracked invalidate the object borrowed by value, and report whether it's found
+func (p *TypedPool[T]) invalidateTracked(ctx context.Context, object T) (bool, error) {
+	p.actionLock.Lock()
+	po := p.lookupObject(object)
+	if po == nil {
+		p.actionLock.Unlock()
+		return false, nil
+	}
+	retired := p.invalidateObject(po)
+	p.actionLock.Unlock()
+	if retired {
+		p.counters.add(&p.counters.invalidations, 1)
+		return true, p.destroyRetired(ctx, po)
+	}
+	return true, nil
+}
+
+//invalidateObject retire the active object, and report whether it should be destroyed
+func (p *TypedPool[T]) invalidateObject(po *pooledObject[T]) bool {
+	if !p.manager.Deactivate(po) {
+		return false
+	}
+	p.retireObject(po)
+	return true
+}
+
+//Return return the borrowed object to pool
+func (p *TypedPool[T]) Return(ctx context.Context, object T) error {
+	found, err := p.returnTracked(ctx, object)
+	if found {
+		return err
+	}
+	if p.destroysUnknown() {
+		//if return after closing, just destroy object
+		return p.destroyObject(ctx, object)
+	}
+	//return a object that not existed
+	return nil
+}
+
+//destroysUnknown report whether the unknown returned objects should be destroyed, i.e. closed but not forcibly
+func (p *TypedPool[T]) destroysUnknown() bool {
+	p.actionLock.RLock()
+	defer p.actionLock.RUnlock()
+	return p.isClosed() && !p.forced
+}
+
+//returnTracked return the object borrowed by value, and report whether it's found
+func (p *TypedPool[T]) returnTracked(ctx context.Context, object T) (bool, error) {
+	if !isComparable(object) {
+		return false, nil
+	}
+	po := p.lookupObject(object)
+	if po == nil {
+		return false, nil
+	}
+	//validate object without lock, only if it's owned by pool
+	valid := p.validateReturning(ctx, object)
+	return true, p.releaseObject(ctx, po, valid)
+}
+
+//rebalance move the idle objects to the other shards having borrowers waiting, if it's a shard
+func (p *TypedPool[T]) rebalance(ctx context.Context) {
+	if p.group != nil {
+		p.group.rebalance(ctx, p)
+	}
+}
+
+//transferIdle pop a idle object to move it to other shard, its budget unit is moved with it
+func (p *TypedPool[T]) transferIdle() *pooledObject[T] {
 	p.actionLock.Lock()
 	defer p.actionLock.Unlock()
+	if p.isClosed() {
+		return nil
+	}
+	po := p.manager.PopNext()
+	if po != nil {
+		p.manager.Unregister(po)
+	}
+	return po
+}
 
+//adopt take over the idle object moved from other shard, and hand it to the longest-waiting borrower
+func (p *TypedPool[T]) adopt(ctx context.Context, po *pooledObject[T]) {
+	p.actionLock.Lock()
 	if p.isClosed() {
-		//if return after closing, just invalidate object
-		return p.invalidateObject(ctx, object)
+		p.reserved++
+		p.actionLock.Unlock()
+		_ = p.destroyRetired(ctx, po)
+		return
+	}
+	p.manager.Adopt(po)
+	p.dispatchIdle()
+	p.actionLock.Unlock()
+}
+
+//released notify the returned object before it's made idle or retired
+func (p *TypedPool[T]) released(ctx context.Context, object T, valid bool) {
+	if !valid {
+		p.listener.OnValidateFail(ctx, object)
 	}
+	p.listener.OnReturn(ctx, object)
+}
 
-	p.manager.Return(object)
+//releaseObject return the valid object, or invalidate it. It must be called without lock.
+//If the object could be idle directly, it's returned without lock.
+func (p *TypedPool[T]) releaseObject(ctx context.Context, po *pooledObject[T], valid bool) error {
+	idle := valid && p.idleReturnable(po)
+	if !po.claim() {
+		//return a object that not active
+		return nil
+	}
+	p.counters.add(&p.counters.returns, 1)
+	//notify before it could be borrowed again
+	p.released(ctx, po.Object(), valid)
+	if idle {
+		p.manager.Release(po)
+		p.manager.Deactivated()
+		p.settleIdle(ctx)
+		p.rebalance(ctx)
+		return nil
+	}
 
-	p.wakeup()
+	p.actionLock.Lock()
+	p.manager.Deactivated()
+	retired := p.settleObject(po, valid)
+	p.actionLock.Unlock()
+	if retired {
+		return p.destroyRetired(ctx, po)
+	}
+	p.rebalance(ctx)
 	return nil
 }
 
-func (p *Pool) wakeup() {
-	//wakeupCh waiting for borrower return/invalidate object
-	//make sure never blocked
-	select {
-	case p.wakeupCh <- struct{}{}:
-	default:
-	}
+//idleReturnable report whether the active object could be returned to idle without lock
+func (p *TypedPool[T]) idleReturnable(po *pooledObject[T]) bool {
+	return p.manager.Lockless() && p.waiters.Len() == 0 && !p.isClosed() &&
+		atomic.LoadInt32(&p.overflowed) == 0 && !po.Expired() && !p.isUsedUp(po)
 }
 
-func (p *Pool) Evict(ctx context.Context) error {
+//settleIdle check again what happened while returning without lock, i.e. borrowers started waiting,
+//the pool closed or MaxSize shrunk, since the object returned may be missed by them
+func (p *TypedPool[T]) settleIdle(ctx context.Context) {
+	if p.waiters.Len() == 0 && !p.isClosed() && atomic.LoadInt32(&p.overflowed) == 0 {
+		return
+	}
+	var idle, surplus []*pooledObject[T]
 	p.actionLock.Lock()
-	defer p.actionLock.Unlock()
+	if p.isClosed() {
+		idle = p.popIdle()
+		p.checkDrained()
+	} else {
+		surplus = p.popSurplus()
+		p.syncLimits()
+		p.dispatchIdle()
+	}
+	p.actionLock.Unlock()
 
+	for _, po := range idle {
+		_ = p.destroyRetired(ctx, po)
+	}
+	p.counters.add(&p.counters.evictions, int64(len(surplus)))
+	for _, po := range surplus {
+		p.listener.OnEvict(ctx, po.Object())
+		_ = p.destroyRetired(ctx, po)
+	}
+}
+
+//returnObject return the active object, and report whether it's retired and should be destroyed
+func (p *TypedPool[T]) returnObject(po *pooledObject[T]) bool {
+	if !p.manager.Deactivate(po) {
+		return false
+	}
+	return p.settleObject(po, true)
+}
+
+//settleObject retire the deactivated object if it's invalid, or hand it off or make it idle.
+//It reports whether the object is retired and should be destroyed.
+func (p *TypedPool[T]) settleObject(po *pooledObject[T], valid bool) bool {
+	if !valid {
+		p.counters.add(&p.counters.validateFailures, 1)
+		p.retireObject(po)
+		return true
+	}
+	if atomic.LoadInt32(&p.overflowed) == 1 {
+		p.syncLimits()
+	}
+	if p.isClosed() || po.Expired() || p.isUsedUp(po) || p.isOverflowed() {
+		//if return after closing, expired, used up or MaxSize shrunk, just invalidate object
+		p.retireObject(po)
+		return true
+	}
+
+	p.dispatchObject(po)
+	return false
+}
+
+//isUsedUp report whether the object has been borrowed MaxUses times
+func (p *TypedPool[T]) isUsedUp(po *pooledObject[T]) bool {
+	maxUses := atomic.LoadInt64(&p.maxUses)
+	return maxUses > 0 && int64(po.BorrowCount()) >= maxUses
+}
+
+func (p *TypedPool[T]) Evict(ctx context.Context) error {
+	p.actionLock.Lock()
 	if p.isClosed() {
+		p.actionLock.Unlock()
 		return ErrPoolClosed
 	}
+	evicted := p.popEvicting()
+	tested := p.popTesting()
+	abandoned, reclaimed := p.popAbandoned()
+	p.actionLock.Unlock()
 
-	minIdle, maxIdle := p.config.MinIdle, p.config.MaxIdle
-	maxSize := p.config.MaxSize
-	minIdleTime := p.config.MinIdleTime
+	p.counters.add(&p.counters.evictions, int64(len(evicted)))
+	for _, po := range evicted {
+		p.listener.OnEvict(ctx, po.Object())
+		_ = p.destroyRetired(ctx, po)
+	}
+	p.reportAbandoned(ctx, abandoned, reclaimed)
+	p.testIdle(ctx, tested)
+	return p.warmup(ctx)
+}
+
+//popAbandoned find abandoned objects, and retire them if RemoveAbandoned enabled
+func (p *TypedPool[T]) popAbandoned() ([]TypedAbandonedObject[T], []*pooledObject[T]) {
+	timeout := p.config.AbandonedTimeout
+	if timeout <= 0 {
+		return nil, nil
+	}
+	var abandoned []TypedAbandonedObject[T]
+	var reclaimed []*pooledObject[T]
+	p.manager.RangeAbandoned(timeout, func(po *pooledObject[T]) {
+		atomic.StoreInt32(&po.abandoned, 1)
+		abandoned = append(abandoned, TypedAbandonedObject[T]{
+			Object:   po.Object(),
+			BorrowAt: po.BorrowAt(),
+			Stack:    po.stack,
+			Removed:  p.config.RemoveAbandoned,
+		})
+		if p.config.RemoveAbandoned {
+			reclaimed = append(reclaimed, po)
+		}
+	})
+	//the objects may be returned without lock meanwhile
+	n := 0
+	for _, po := range reclaimed {
+		if p.invalidateObject(po) {
+			reclaimed[n] = po
+			n++
+		}
+	}
+	return abandoned, reclaimed[:n]
+}
+
+//reportAbandoned report the abandoned objects and destroy the reclaimed without lock
+func (p *TypedPool[T]) reportAbandoned(ctx context.Context, abandoned []TypedAbandonedObject[T], reclaimed []*pooledObject[T]) {
+	p.counters.add(&p.counters.abandoned, int64(len(abandoned)))
+	if handler := p.config.OnAbandoned; handler != nil {
+		for _, a := range abandoned {
+			handler(ctx, a)
+		}
+	}
+	for _, po := range reclaimed {
+		_ = p.destroyRetired(ctx, po)
+	}
+}
 
-	//protect config
+//idleLimits return the protected MinIdle and MaxIdle
+func (p *TypedPool[T]) idleLimits() (int, int) {
+	minIdle, maxIdle := p.config.MinIdle, p.config.MaxIdle
 	if maxIdle < 0 {
 		maxIdle = 0
 	}
@@ -224,76 +1071,311 @@ func (p *Pool) Evict(ctx context.Context) error {
 	if minIdle > maxIdle {
 		minIdle = maxIdle
 	}
+	return minIdle, maxIdle
+}
+
+//popEvicting retire expired idle objects and idle objects exceeding MaxIdle
+func (p *TypedPool[T]) popEvicting() []*pooledObject[T] {
+	_, maxIdle := p.idleLimits()
+	minIdleTime := p.config.MinIdleTime
+
+	//evict: pop all expired idle objects
+	evicted := p.manager.PopExpired()
+	p.reserved += len(evicted)
 
 	//evict: pop all idle objects exceed maxIdle
 	evicting := p.manager.IdleSize() - maxIdle
 	for i := 0; i < evicting; i++ {
 		earliest := p.manager.Earliest()
-		if earliest.IdleTime() < minIdleTime {
+		if earliest == nil || earliest.IdleTime() < minIdleTime {
 			break
 		}
+		evicted = append(evicted, p.manager.PopEarliest())
+		p.reserved++
+	}
+	return evicted
+}
 
-		if !p.evictEarliest(ctx) {
-			break
+//popTesting pop the idle objects to test if TestWhileIdle enabled, their slots are reserved.
+//Objects are picked round-robin by the order returned, from where the last run stopped,
+//so that all idle objects are tested in turn.
+func (p *TypedPool[T]) popTesting() []*pooledObject[T] {
+	if !p.config.TestWhileIdle {
+		return nil
+	}
+	n := p.manager.IdleSize()
+	if p.config.TestsPerEvictRun > 0 && p.config.TestsPerEvictRun < n {
+		n = p.config.TestsPerEvictRun
+	}
+	if n == 0 {
+		return nil
+	}
+	tested := p.manager.PopReturnedAfter(p.testedUntil, n)
+	if len(tested) < n && !p.testedUntil.IsZero() {
+		//start a new round from the earliest one
+		tested = append(tested, p.manager.PopReturnedAfter(time.Time{}, n-len(tested))...)
+	}
+	if len(tested) > 0 {
+		p.testedUntil = tested[len(tested)-1].ReturnAt()
+	}
+	p.reserved += len(tested)
+	return tested
+}
+
+//testIdle test the popped idle objects without lock, restore the passed and destroy the failed
+func (p *TypedPool[T]) testIdle(ctx context.Context, tested []*pooledObject[T]) {
+	if len(tested) == 0 {
+		return
+	}
+	passed := make([]bool, len(tested))
+	for i, po := range tested {
+		passed[i] = p.keepalive(ctx, po.Object())
+		if !passed[i] {
+			p.counters.add(&p.counters.validateFailures, 1)
+			p.listener.OnValidateFail(ctx, po.Object())
+		}
+	}
+
+	failed := make([]*pooledObject[T], 0)
+	p.actionLock.Lock()
+	//restored by the order returned, so that the eviction order is kept
+	for i := range tested {
+		if !passed[i] || p.isClosed() {
+			failed = append(failed, tested[i])
+			continue
 		}
+		p.reserved--
+		p.manager.Restore(tested[i])
 	}
+	p.dispatchIdle()
+	p.actionLock.Unlock()
 
-	//warmup: ensure there are at least minIdle objects
-	warmup := minIdle - p.manager.IdleSize()
-	newSize := p.manager.Size() + warmup
-	if newSize > maxSize {
-		warmup = maxSize - p.manager.Size()
+	p.counters.add(&p.counters.evictions, int64(len(failed)))
+	for _, po := range failed {
+		p.listener.OnEvict(ctx, po.Object())
+		_ = p.destroyRetired(ctx, po)
 	}
-	for i := 0; i < warmup; i++ {
-		if err := p.createObject(ctx); err != nil {
-			return err
+}
+
+func (p *TypedPool[T]) keepalive(ctx context.Context, object T) bool {
+	if kFactory := p.config.ObjectKeepaliveFactory; kFactory != nil {
+		return kFactory(ctx, object) == nil
+	}
+	return p.validateObject(ctx, object)
+}
+
+//dispatchIdle hand idle objects to waiters
+func (p *TypedPool[T]) dispatchIdle() {
+	for p.waiters.Len() > 0 {
+		po := p.manager.Borrow()
+		if po == nil {
+			return
 		}
+		w := p.waiters.Dequeue()
+		w.ch <- waitResult[T]{po: po}
 	}
-	return nil
 }
 
-func (p *Pool) evictEarliest(ctx context.Context) bool {
-	popped := p.manager.PopEarliest()
-	if popped == nil {
+//earliestIdleTime return the idle time of the earliest idle object
+func (p *TypedPool[T]) earliestIdleTime() (time.Duration, bool) {
+	p.actionLock.Lock()
+	defer p.actionLock.Unlock()
+	earliest := p.manager.Earliest()
+	if earliest == nil {
+		return 0, false
+	}
+	return earliest.IdleTime(), true
+}
+
+//evictEarliest evict the earliest idle object to free its slot
+func (p *TypedPool[T]) evictEarliest(ctx context.Context) bool {
+	p.actionLock.Lock()
+	po := p.manager.PopEarliest()
+	if po == nil {
+		p.actionLock.Unlock()
 		return false
 	}
-	_ = p.destroyObject(ctx, popped.Object())
+	p.reserved++
+	p.actionLock.Unlock()
+
+	p.counters.add(&p.counters.evictions, 1)
+	p.listener.OnEvict(ctx, po.Object())
+	_ = p.destroyRetired(ctx, po)
 	return true
 }
 
-func (p *Pool) destroyObject(ctx context.Context, object interface{}) error {
-	if object == nil || p.config.ObjectDestroyFactory == nil {
+//warmup ensure there are at least MinIdle objects
+func (p *TypedPool[T]) warmup(ctx context.Context) error {
+	p.actionLock.Lock()
+	minIdle, _ := p.idleLimits()
+	warmup := 0
+	for !p.isClosed() && warmup < minIdle-p.manager.IdleSize() && p.reserveSlot() {
+		warmup++
+	}
+	p.actionLock.Unlock()
+
+	for i := 0; i < warmup; i++ {
+		po, err := p.createObject(ctx, false)
+		if err != nil {
+			//release the rest slots
+			p.actionLock.Lock()
+			for j := i + 1; j < warmup; j++ {
+				p.releaseSlot()
+			}
+			p.actionLock.Unlock()
+			return err
+		}
+		p.actionLock.Lock()
+		retired := p.returnObject(po)
+		p.actionLock.Unlock()
+		if retired {
+			_ = p.destroyRetired(ctx, po)
+		}
+	}
+	return nil
+}
+
+func (p *TypedPool[T]) destroyObject(ctx context.Context, object T) error {
+	p.counters.add(&p.counters.destroys, 1)
+	if interface{}(object) == nil || p.config.ObjectDestroyFactory == nil {
+		p.listener.OnDestroy(ctx, object, nil)
 		return nil
 	}
-	return p.config.ObjectDestroyFactory(ctx, object)
+	begin := time.Now()
+	err := p.config.ObjectDestroyFactory(ctx, object)
+	p.histograms.destroy.Since(begin)
+	p.listener.OnDestroy(ctx, object, err)
+	return err
 }
 
-func (p *Pool) StartEvictor() {
-	for range p.evictorTicker.C {
-		_ = p.Evict(context.Background())
+func (p *TypedPool[T]) StartEvictor() {
+	p.actionLock.RLock()
+	ticker, stop := p.evictorTicker, p.evictorStop
+	p.actionLock.RUnlock()
+	if ticker == nil {
+		return
 	}
+	p.runEvictor(ticker, stop)
 }
 
-func (p *Pool) Close(ctx context.Context) error {
-	p.actionLock.Lock()
-	defer p.actionLock.Unlock()
+//startEvictor start the evictor goroutine, it could be joined by evictorGroup
+func (p *TypedPool[T]) startEvictor() {
+	ticker := time.NewTicker(p.config.EvictInterval)
+	stop := make(chan struct{})
+	p.evictorTicker, p.evictorStop = ticker, stop
+	p.evictorGroup.Add(1)
+	go func() {
+		defer p.evictorGroup.Done()
+		p.runEvictor(ticker, stop)
+	}()
+}
+
+//stopEvictor stop the evictor goroutine if started
+func (p *TypedPool[T]) stopEvictor() {
+	if p.evictorTicker == nil {
+		return
+	}
+	p.evictorTicker.Stop()
+	close(p.evictorStop)
+	p.evictorTicker, p.evictorStop = nil, nil
+}
 
+func (p *TypedPool[T]) runEvictor(ticker *time.Ticker, stop chan struct{}) {
+	for {
+		select {
+		case <-ticker.C:
+			_ = p.Evict(context.Background())
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (p *TypedPool[T]) Close(ctx context.Context) error {
+	p.actionLock.Lock()
 	if p.isClosed() {
+		p.actionLock.Unlock()
 		return ErrPoolClosed
 	}
-	p.closed = true
-	close(p.wakeupCh)
+	p.close(ctx)
+	return nil
+}
 
-	if p.evictorTicker != nil {
-		p.evictorTicker.Stop()
+//closeIfUnused close the pool if there is no any object or waiter, and report whether it's closed
+func (p *TypedPool[T]) closeIfUnused(ctx context.Context) bool {
+	p.actionLock.Lock()
+	if p.isClosed() || p.manager.Size() > 0 || p.reserved > 0 || p.waiters.Len() > 0 {
+		p.actionLock.Unlock()
+		return false
 	}
+	p.close(ctx)
+	return true
+}
 
-	//destroy all idle objects
+//close close the pool with lock, and unlock it before destroying idle objects
+func (p *TypedPool[T]) close(ctx context.Context) {
+	atomic.StoreInt32(&p.closed, 1)
+	//wakeup all waiters, they will find pool closed
+	for w := p.waiters.Dequeue(); w != nil; w = p.waiters.Dequeue() {
+		w.ch <- waitResult[T]{}
+	}
+
+	p.stopEvictor()
+	if p.refilling != nil {
+		p.refilling.Stop()
+		p.refilling = nil
+	}
+
+	//pop all idle objects
 	//Close function will not close any active object
-	//But active object should be destroyed after borrow function waken
-	p.manager.RangeIdle(func(object interface{}) {
-		_ = p.destroyObject(ctx, object)
-	})
+	//But active object should be destroyed after returned
+	idle := p.popIdle()
+	p.checkDrained()
+	p.actionLock.Unlock()
 
-	return nil
+	for _, po := range idle {
+		_ = p.destroyRetired(ctx, po)
+	}
+	p.listener.OnClose(ctx)
+}
+
+//popIdle retire all idle objects
+func (p *TypedPool[T]) popIdle() []*pooledObject[T] {
+	idle := make([]*pooledObject[T], 0, p.manager.IdleSize())
+	for po := p.manager.PopEarliest(); po != nil; po = p.manager.PopEarliest() {
+		idle = append(idle, po)
+	}
+	p.reserved += len(idle)
+	return idle
+}
+
+//Shutdown close the pool, and wait until all active objects are returned and destroyed, or ctx is done.
+//Then the active objects not returned are destroyed forcibly, and the number of them is reported with ctx.Err().
+//The evictor is also stopped and joined. It could be called after Close.
+func (p *TypedPool[T]) Shutdown(ctx context.Context) (int, error) {
+	_ = p.Close(ctx)
+	defer p.evictorGroup.Wait()
+
+	select {
+	case <-p.drained:
+		return 0, nil
+	case <-ctx.Done():
+	}
+
+	p.actionLock.Lock()
+	p.forced = true
+	active := make([]*pooledObject[T], 0)
+	for _, po := range p.manager.Actives() {
+		//the objects may be returned without lock meanwhile
+		if p.invalidateObject(po) {
+			active = append(active, po)
+		}
+	}
+	p.actionLock.Unlock()
+
+	//ctx is done, so destroy them with a new one
+	for _, po := range active {
+		_ = p.destroyRetired(context.Background(), po)
+	}
+	return len(active), ctx.Err()
 }
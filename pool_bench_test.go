@@ -3,6 +3,7 @@ package pond
 import (
 	"context"
 	"github.com/jolestar/go-commons-pool/v2"
+	"runtime"
 	"strconv"
 	"testing"
 	"time"
@@ -17,6 +18,15 @@ func getPool() (*Pool, error) {
 	return New(cfg)
 }
 
+func getShardedPool() (*ShardedPool, error) {
+	cfg := NewConfig(testObjectCreateFactory)
+	cfg.MaxSize = 8
+	cfg.MaxIdle = 8
+	cfg.MinIdle = 0
+	cfg.MinIdleTime = 30 * time.Minute
+	return NewSharded(cfg, 0)
+}
+
 func getCommonPool() *pool.ObjectPool {
 	factory := pool.NewPooledObjectFactorySimple(
 		func(ctx context.Context) (interface{}, error) {
@@ -54,6 +64,23 @@ func BenchmarkPool(b *testing.B) {
 	}
 }
 
+//BenchmarkPoolSharedContext is BenchmarkPool without building a context per borrowing,
+//so that only the allocations of pool are counted.
+func BenchmarkPoolSharedContext(b *testing.B) {
+	ctx := context.WithValue(context.Background(), contextKeyName{}, "bench")
+	p, _ := getPool()
+	for i := 0; i < b.N; i++ {
+		obj, err := p.BorrowObject(ctx)
+		if err != nil {
+			panic(err)
+		}
+		err = p.ReturnObject(ctx, obj)
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
 func BenchmarkPoolWithConcurrent(b *testing.B) {
 	ctx := context.Background()
 	p, _ := getPool()
@@ -71,6 +98,54 @@ func BenchmarkPoolWithConcurrent(b *testing.B) {
 	})
 }
 
+func BenchmarkShardedPoolWithConcurrent(b *testing.B) {
+	ctx := context.Background()
+	p, _ := getShardedPool()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			lease, err := p.BorrowHandle(ctx)
+			if err != nil {
+				panic(err)
+			}
+			err = lease.Release(ctx)
+			if err != nil {
+				panic(err)
+			}
+		}
+	})
+}
+
+//BenchmarkScaling compare pool and sharded pool when there is always a object for each core.
+//Run with -cpu to show how they scale with cores, e.g. -cpu=1,8,64.
+func BenchmarkScaling(b *testing.B) {
+	ctx := context.Background()
+	cfg := NewConfig(testObjectCreateFactory)
+	cfg.MaxSize = runtime.GOMAXPROCS(0)
+	cfg.MaxIdle = cfg.MaxSize
+	cfg.AutoEvict = false
+
+	b.Run("pool", func(b *testing.B) {
+		p, _ := NewTyped(cfg)
+		defer p.Close(ctx)
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				lease, _ := p.BorrowHandle(ctx)
+				_ = lease.Release(ctx)
+			}
+		})
+	})
+	b.Run("sharded", func(b *testing.B) {
+		p, _ := NewTypedSharded(cfg, 0)
+		defer p.Close(ctx)
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				lease, _ := p.BorrowHandle(ctx)
+				_ = lease.Release(ctx)
+			}
+		})
+	})
+}
+
 func BenchmarkCommonPool(b *testing.B) {
 	ctx := context.Background()
 	p := getCommonPool()
@@ -92,6 +167,21 @@ func BenchmarkCommonPool(b *testing.B) {
 	}
 }
 
+func BenchmarkCommonPoolSharedContext(b *testing.B) {
+	ctx := context.WithValue(context.Background(), contextKeyName{}, "bench")
+	p := getCommonPool()
+	for i := 0; i < b.N; i++ {
+		obj, err := p.BorrowObject(ctx)
+		if err != nil {
+			panic(err)
+		}
+		err = p.ReturnObject(ctx, obj)
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
 func BenchmarkCommonPoolWithConcurrent(b *testing.B) {
 	ctx := context.Background()
 	p := getCommonPool()
@@ -0,0 +1,146 @@
+package pond
+
+import (
+	"context"
+	"time"
+)
+
+//UpdateConfig update the config at runtime. The update function is called with lock, so it must not call the pool.
+//Only the limits and evictor options take effect, i.e. MaxSize, MinIdle, MaxIdle, MinIdleTime, Nonblocking, MaxWait,
+//AutoEvict, EvictInterval, TestWhileIdle, TestsPerEvictRun, MaxLifetime, LifetimeJitter, MaxUses,
+//AbandonedTimeout and RemoveAbandoned. The others are ignored.
+//
+//Growing MaxSize wakes up waiters to create objects. Shrinking MaxSize destroys the surplus idle objects,
+//and the surplus active objects will be destroyed when returned.
+//The updated config is validated, and nothing is changed if it's invalid.
+func (p *TypedPool[T]) UpdateConfig(update func(config *TypedConfig[T])) error {
+	return p.updateConfig(func(config *TypedConfig[T]) error {
+		update(config)
+		return nil
+	})
+}
+
+//updateConfig update the config, nothing is changed if update failed
+func (p *TypedPool[T]) updateConfig(update func(config *TypedConfig[T]) error) error {
+	p.actionLock.Lock()
+	if p.isClosed() {
+		p.actionLock.Unlock()
+		return ErrPoolClosed
+	}
+	config := p.config
+	if err := update(&config); err != nil {
+		p.actionLock.Unlock()
+		return err
+	}
+	evictor := p.config.AutoEvict != config.AutoEvict || p.config.EvictInterval != config.EvictInterval
+	//validate the config with ignored fields unchanged
+	updated := p.config
+	updated.applyRuntime(config)
+	if err := updated.Validate(); err != nil {
+		p.actionLock.Unlock()
+		return err
+	}
+	p.config.applyRuntime(config)
+
+	if evictor {
+		p.resetEvictor()
+	}
+	surplus := p.popSurplus()
+	p.syncLimits()
+	for p.dispatchSlot() {
+	}
+	p.actionLock.Unlock()
+
+	ctx := context.Background()
+	p.counters.add(&p.counters.evictions, int64(len(surplus)))
+	for _, po := range surplus {
+		p.listener.OnEvict(ctx, po.Object())
+		_ = p.destroyRetired(ctx, po)
+	}
+	return nil
+}
+
+//SetMaxSize update MaxSize at runtime
+func (p *TypedPool[T]) SetMaxSize(maxSize int) error {
+	return p.UpdateConfig(func(config *TypedConfig[T]) {
+		config.MaxSize = maxSize
+	})
+}
+
+//SetMinIdle update MinIdle at runtime
+func (p *TypedPool[T]) SetMinIdle(minIdle int) error {
+	return p.UpdateConfig(func(config *TypedConfig[T]) {
+		config.MinIdle = minIdle
+	})
+}
+
+//SetMaxIdle update MaxIdle at runtime
+func (p *TypedPool[T]) SetMaxIdle(maxIdle int) error {
+	return p.UpdateConfig(func(config *TypedConfig[T]) {
+		config.MaxIdle = maxIdle
+	})
+}
+
+//SetEvictInterval update EvictInterval at runtime, and reset the evictor
+func (p *TypedPool[T]) SetEvictInterval(interval time.Duration) error {
+	return p.UpdateConfig(func(config *TypedConfig[T]) {
+		config.EvictInterval = interval
+	})
+}
+
+//SetNonblocking update Nonblocking at runtime
+func (p *TypedPool[T]) SetNonblocking(nonblocking bool) error {
+	return p.UpdateConfig(func(config *TypedConfig[T]) {
+		config.Nonblocking = nonblocking
+	})
+}
+
+//applyRuntime copy the fields which could be updated at runtime
+func (c *TypedConfig[T]) applyRuntime(config TypedConfig[T]) {
+	c.MaxSize = config.MaxSize
+	c.MinIdle = config.MinIdle
+	c.MaxIdle = config.MaxIdle
+	c.MinIdleTime = config.MinIdleTime
+	c.Nonblocking = config.Nonblocking
+	c.MaxWait = config.MaxWait
+	c.AutoEvict = config.AutoEvict
+	c.EvictInterval = config.EvictInterval
+	c.TestWhileIdle = config.TestWhileIdle
+	c.TestsPerEvictRun = config.TestsPerEvictRun
+	c.MaxLifetime = config.MaxLifetime
+	c.LifetimeJitter = config.LifetimeJitter
+	c.MaxUses = config.MaxUses
+	c.AbandonedTimeout = config.AbandonedTimeout
+	c.RemoveAbandoned = config.RemoveAbandoned
+}
+
+//resetEvictor start, stop or reset the evictor by AutoEvict and EvictInterval
+func (p *TypedPool[T]) resetEvictor() {
+	if !p.config.AutoEvict {
+		p.stopEvictor()
+		return
+	}
+	if p.evictorTicker == nil {
+		p.startEvictor()
+		return
+	}
+	p.evictorTicker.Reset(p.config.EvictInterval)
+}
+
+//popSurplus retire the earliest idle objects exceeding MaxSize
+func (p *TypedPool[T]) popSurplus() []*pooledObject[T] {
+	if p.config.MaxSize <= 0 {
+		return nil
+	}
+	surplus := p.manager.Size() + p.reserved - p.config.MaxSize
+	popped := make([]*pooledObject[T], 0)
+	for i := 0; i < surplus; i++ {
+		po := p.manager.PopEarliest()
+		if po == nil {
+			break
+		}
+		popped = append(popped, po)
+	}
+	p.reserved += len(popped)
+	return popped
+}
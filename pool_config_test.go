@@ -0,0 +1,132 @@
+package pond
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPoolGrowMaxSize(t *testing.T) {
+	ctx := context.Background()
+	cfg := NewConfig(testObjectCreateFactory)
+	cfg.MaxSize = 1
+	cfg.AutoEvict = false
+	p, _ := New(cfg)
+	defer p.Close(ctx)
+
+	obj, err := p.BorrowObject(ctx)
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := p.BorrowObject(ctx)
+			assert.NoError(t, err)
+		}()
+	}
+	time.Sleep(time.Millisecond * 10)
+	assert.Equal(t, 2, waitersLen(p.TypedPool))
+
+	//waiters are waken to create objects
+	assert.NoError(t, p.SetMaxSize(3))
+	wg.Wait()
+	assert.Equal(t, 3, p.ActiveSize())
+	assert.Equal(t, 3, p.Stats().MaxSize)
+	assert.NoError(t, p.ReturnObject(ctx, obj))
+}
+
+func TestPoolShrinkMaxSize(t *testing.T) {
+	ctx := context.Background()
+	cfg := NewConfig(testObjectCreateFactory)
+	cfg.MaxSize = 4
+	cfg.AutoEvict = false
+	p, _ := New(cfg)
+	defer p.Close(ctx)
+
+	objs := make([]interface{}, 4)
+	for i := range objs {
+		objs[i], _ = p.BorrowObject(ctx)
+	}
+	assert.NoError(t, p.ReturnObject(ctx, objs[0]))
+	assert.NoError(t, p.ReturnObject(ctx, objs[1]))
+
+	//surplus idle objects are destroyed
+	assert.NoError(t, p.SetMaxSize(1))
+	assert.Equal(t, 0, p.IdleSize())
+	assert.Equal(t, 2, p.ActiveSize())
+	assert.Equal(t, int64(2), p.Stats().Evictions)
+
+	//surplus active objects are destroyed when returned
+	assert.NoError(t, p.ReturnObject(ctx, objs[2]))
+	assert.Equal(t, 0, p.IdleSize())
+	assert.NoError(t, p.ReturnObject(ctx, objs[3]))
+	assert.Equal(t, 1, p.IdleSize())
+	assert.Equal(t, int64(3), p.Stats().Destroys)
+
+	cctx, cancel := context.WithTimeout(ctx, time.Millisecond*10)
+	defer cancel()
+	obj, err := p.BorrowObject(cctx)
+	assert.NoError(t, err)
+	_, err = p.BorrowObject(cctx)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.NoError(t, p.ReturnObject(ctx, obj))
+}
+
+func TestPoolUpdateConfig(t *testing.T) {
+	ctx := context.Background()
+	cfg := NewConfig(testObjectCreateFactory)
+	cfg.MaxSize = 1
+	cfg.AutoEvict = false
+	p, _ := New(cfg)
+
+	obj, _ := p.BorrowObject(ctx)
+	assert.NoError(t, p.SetNonblocking(true))
+	_, err := p.BorrowObject(ctx)
+	assert.Equal(t, ErrPoolExhausted, err)
+	assert.NoError(t, p.ReturnObject(ctx, obj))
+
+	err = p.SetMinIdle(2)
+	assert.Equal(t, &ConfigError{Field: "MinIdle", Reason: "must not be greater than MaxSize 1, got 2"}, err)
+	assert.NoError(t, p.SetMaxSize(2))
+	assert.NoError(t, p.SetMinIdle(2))
+	assert.NoError(t, p.SetMaxIdle(2))
+	stats := p.Stats()
+	assert.Equal(t, 2, stats.MinIdle)
+	assert.Equal(t, 2, stats.MaxIdle)
+
+	//ignored
+	assert.NoError(t, p.UpdateConfig(func(config *Config) {
+		config.MaxValidateAttempts = 10
+		config.ObjectCreateFactory = nil
+	}))
+	assert.Equal(t, DefaultMaxValidateAttempts, p.config.MaxValidateAttempts)
+	assert.NotNil(t, p.config.ObjectCreateFactory)
+
+	//start evictor
+	err = p.UpdateConfig(func(config *Config) {
+		config.AutoEvict = true
+		config.EvictInterval = 0
+	})
+	assert.Equal(t, &ConfigError{Field: "EvictInterval", Reason: "must be positive when AutoEvict enabled, got 0s"}, err)
+	assert.NoError(t, p.UpdateConfig(func(config *Config) {
+		config.AutoEvict = true
+		config.EvictInterval = time.Millisecond
+	}))
+	//warmup by evictor
+	time.Sleep(time.Millisecond * 50)
+	assert.Equal(t, 2, p.IdleSize())
+
+	//reset and stop evictor
+	assert.NoError(t, p.SetEvictInterval(time.Hour))
+	assert.NoError(t, p.UpdateConfig(func(config *Config) {
+		config.AutoEvict = false
+	}))
+	_, err = p.Shutdown(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, ErrPoolClosed, p.SetMaxSize(1))
+}
@@ -1,94 +1,444 @@
 package pond
 
-//poolManager is not thread-safe
-type poolManager struct {
-	idle   *pooledStack
-	active map[interface{}]*pooledObject
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//poolManager is not thread-safe, except the methods noted to be called without lock.
+//The idle objects of LIFO order are pushed to a lock-free stack first, so that borrowing and returning them
+//don't need lock. They are flushed to the idle store before the idle store is accessed in order, e.g. by evictor.
+type poolManager[T any] struct {
+	idle    idleStore[T]
+	hot     *idleStack[T] //idle objects returned latest, nil if not LIFO
+	objects map[*pooledObject[T]]struct{}
+	size    int64 //number of idle and active objects, changed with lock but read atomically
+	active  int64 //number of active objects, changed atomically
+	stamp   int32 //whether the borrowing time is recorded, i.e. abandoned objects are detected
+	//index of objects ever borrowed by value, objects equal in value share the same key.
+	//The values are []*pooledObject[T] copied on write, so it could be read without lock.
+	index sync.Map
 }
 
-func newPoolManager() *poolManager {
-	return &poolManager{
-		idle:   newPooledStack(),
-		active: make(map[interface{}]*pooledObject),
+func newPoolManager[T any](order IdleOrder) *poolManager[T] {
+	p := &poolManager[T]{
+		idle:    newIdleStore[T](order),
+		objects: make(map[*pooledObject[T]]struct{}),
+	}
+	if order == IdleOrderLIFO {
+		p.hot = newIdleStack[T]()
 	}
+	return p
+}
+
+//Lockless report whether idle objects could be borrowed and returned without lock
+func (p *poolManager[T]) Lockless() bool {
+	return p.hot != nil
 }
 
-func (p *poolManager) Earliest() *pooledObject {
+//flush move the hot idle objects to idle store, they are always returned later than the idle store ones
+func (p *poolManager[T]) flush() {
+	if p.hot == nil || p.hot.Len() == 0 {
+		return
+	}
+	var hot []*pooledObject[T]
+	for po := p.hot.Pop(); po != nil; po = p.hot.Pop() {
+		hot = append(hot, po)
+	}
+	for i := len(hot) - 1; i >= 0; i-- {
+		p.idle.Push(hot[i])
+	}
+}
+
+func (p *poolManager[T]) Earliest() *pooledObject[T] {
+	p.flush()
 	return p.idle.Bottom()
 }
 
-func (p *poolManager) PopEarliest() *pooledObject {
-	return p.idle.BPop()
+func (p *poolManager[T]) PopEarliest() *pooledObject[T] {
+	p.flush()
+	po := p.idle.BPop()
+	if po != nil {
+		p.addSize(-1)
+	}
+	return po
 }
 
-func (p *poolManager) Latest() *pooledObject {
+//Restore push the popped idle object back by the order returned
+func (p *poolManager[T]) Restore(po *pooledObject[T]) {
+	p.flush()
+	p.idle.Restore(po)
+	p.addSize(1)
+}
+
+//PopReturnedAfter pop up to n earliest idle objects returned after the time
+func (p *poolManager[T]) PopReturnedAfter(after time.Time, n int) []*pooledObject[T] {
+	p.flush()
+	picked := make([]*pooledObject[T], 0, n)
+	p.idle.Range(func(po *pooledObject[T]) {
+		if len(picked) < n && po.ReturnAt().After(after) {
+			picked = append(picked, po)
+		}
+	})
+	if len(picked) == 0 {
+		return nil
+	}
+	//picked by order, so they are matched one by one
+	i := 0
+	p.idle.RemoveIf(func(po *pooledObject[T]) bool {
+		if i < len(picked) && po == picked[i] {
+			i++
+			return true
+		}
+		return false
+	})
+	p.addSize(-len(picked))
+	return picked
+}
+
+//PopExpired pop all expired idle objects
+func (p *poolManager[T]) PopExpired() []*pooledObject[T] {
+	p.flush()
+	expired := p.idle.RemoveIf(func(po *pooledObject[T]) bool {
+		return po.Expired()
+	})
+	p.addSize(-len(expired))
+	return expired
+}
+
+//RangeAbandoned iterate active objects borrowed longer than timeout and not reported yet
+func (p *poolManager[T]) RangeAbandoned(timeout time.Duration, fn func(po *pooledObject[T])) {
+	for po := range p.objects {
+		if po.IsActive() && atomic.LoadInt32(&po.abandoned) == 0 && po.BorrowTime() >= timeout {
+			fn(po)
+		}
+	}
+}
+
+//Next return the next idle object to borrow
+func (p *poolManager[T]) Next() *pooledObject[T] {
+	p.flush()
 	return p.idle.Top()
 }
 
-func (p *poolManager) PopLatest() *pooledObject {
+//PopNext pop the next idle object to move it out of pool
+func (p *poolManager[T]) PopNext() *pooledObject[T] {
+	po := p.pop()
+	if po != nil {
+		p.addSize(-1)
+	}
+	return po
+}
+
+func (p *poolManager[T]) pop() *pooledObject[T] {
+	if p.hot != nil {
+		if po := p.hot.Pop(); po != nil {
+			return po
+		}
+	}
 	return p.idle.Pop()
 }
 
-func (p *poolManager) Borrow() *pooledObject {
-	po := p.PopLatest()
+func (p *poolManager[T]) Borrow() *pooledObject[T] {
+	po := p.pop()
+	if po == nil {
+		return nil
+	}
+	p.activate(po, stateActive)
+	return po
+}
+
+//TryBorrow borrow a hot idle object. It could be called without lock.
+func (p *poolManager[T]) TryBorrow() *pooledObject[T] {
+	if p.hot == nil {
+		return nil
+	}
+	//counted before popped, so that a closed pool is not drained while borrowing
+	atomic.AddInt64(&p.active, 1)
+	po := p.hot.Pop()
 	if po == nil {
+		atomic.AddInt64(&p.active, -1)
 		return nil
 	}
-	p.active[po.Object()] = po
+	p.Borrowed(po)
+	atomic.StoreInt32(&po.state, stateActive)
 	return po
 }
 
-func (p *poolManager) Create(object interface{}) {
-	po, existed := p.active[object]
-	//object is nil or existed in active
-	if object == nil || existed {
+func (p *poolManager[T]) activate(po *pooledObject[T], state int32) {
+	p.Borrowed(po)
+	atomic.StoreInt32(&po.state, state)
+	atomic.AddInt64(&p.active, 1)
+}
+
+//Borrowed count the borrowing of object. It could be called without lock.
+func (p *poolManager[T]) Borrowed(po *pooledObject[T]) {
+	po.Borrowed(atomic.LoadInt32(&p.stamp) == 1)
+}
+
+//StampBorrow set whether the borrowing time is recorded, since time.Now is too expensive to call on every borrowing.
+//When it's enabled, all objects are stamped with now, so that none is abandoned before the timeout passed since then.
+func (p *poolManager[T]) StampBorrow(stamp bool) {
+	if !stamp {
+		atomic.StoreInt32(&p.stamp, 0)
+		return
+	}
+	if atomic.LoadInt32(&p.stamp) == 1 {
+		return
+	}
+	atomic.StoreInt32(&p.stamp, 1)
+	now := time.Now()
+	for po := range p.objects {
+		po.Stamp(now)
+	}
+}
+
+func (p *poolManager[T]) Create(object T) {
+	//object is nil
+	if interface{}(object) == nil {
 		return
 	}
 	//create new one
-	po = newPooledObject(object)
-	p.idle.Push(po)
+	po := newPooledObject(object)
+	p.register(po)
+	p.push(po)
+	p.addSize(1)
 }
 
-func (p *poolManager) Return(object interface{}) {
-	po := p.active[object]
-	if po == nil {
-		//return a object that not existed
+//Adopt push the idle object moved from other pool
+func (p *poolManager[T]) Adopt(po *pooledObject[T]) {
+	p.register(po)
+	p.push(po)
+	p.addSize(1)
+}
+
+//Activate create a new active object
+func (p *poolManager[T]) Activate(object T) *pooledObject[T] {
+	po := newPooledObject(object)
+	p.register(po)
+	atomic.StoreInt32(&po.state, stateActive)
+	atomic.AddInt64(&p.active, 1)
+	p.addSize(1)
+	return po
+}
+
+//Handoff keep the deactivated object active for the next borrower
+func (p *poolManager[T]) Handoff(po *pooledObject[T]) {
+	p.activate(po, stateActive)
+}
+
+//Indexed report whether the object has been indexed by value. It could be called without lock.
+func (p *poolManager[T]) Indexed(po *pooledObject[T]) bool {
+	return atomic.LoadInt32(&po.indexed) == 1
+}
+
+//Index index the object by value, the object must be comparable
+func (p *poolManager[T]) Index(po *pooledObject[T]) {
+	if _, ok := p.objects[po]; !ok || p.Indexed(po) {
+		return
+	}
+	object := interface{}(po.Object())
+	var pos []*pooledObject[T]
+	if v, ok := p.index.Load(object); ok {
+		pos = v.([]*pooledObject[T])
+	}
+	p.index.Store(object, append(pos[:len(pos):len(pos)], po))
+	atomic.StoreInt32(&po.indexed, 1)
+}
+
+//Track mark the indexed active object to be found by value. It could be called without lock.
+func (p *poolManager[T]) Track(po *pooledObject[T]) {
+	atomic.CompareAndSwapInt32(&po.state, stateActive, stateTracked)
+}
+
+//Lookup find the active object tracked by value, the object must be comparable. It could be called without lock.
+func (p *poolManager[T]) Lookup(object T) *pooledObject[T] {
+	v, ok := p.index.Load(interface{}(object))
+	if !ok {
+		return nil
+	}
+	pos := v.([]*pooledObject[T])
+	for i := len(pos) - 1; i >= 0; i-- {
+		if atomic.LoadInt32(&pos[i].state) == stateTracked {
+			return pos[i]
+		}
+	}
+	return nil
+}
+
+//register add the new object, so that it could be pushed to hot stack
+func (p *poolManager[T]) register(po *pooledObject[T]) {
+	p.objects[po] = struct{}{}
+	if p.hot != nil {
+		p.hot.Register(po)
+	}
+}
+
+//Unregister remove the index of object destroyed or moved to other pool
+func (p *poolManager[T]) Unregister(po *pooledObject[T]) {
+	if _, ok := p.objects[po]; !ok {
+		return
+	}
+	delete(p.objects, po)
+	if p.hot != nil {
+		p.hot.Unregister(po)
+	}
+	if !p.Indexed(po) {
+		return
+	}
+	atomic.StoreInt32(&po.indexed, 0)
+	object := interface{}(po.Object())
+	v, ok := p.index.Load(object)
+	if !ok {
 		return
 	}
-	delete(p.active, object)
+	pos := v.([]*pooledObject[T])
+	rest := make([]*pooledObject[T], 0, len(pos))
+	for _, o := range pos {
+		if o != po {
+			rest = append(rest, o)
+		}
+	}
+	if len(rest) == 0 {
+		p.index.Delete(object)
+	} else {
+		p.index.Store(object, rest)
+	}
+}
+
+func (p *poolManager[T]) IsActive(po *pooledObject[T]) bool {
+	return po.IsActive()
+}
+
+//Return make the active object idle, and report whether it's active
+func (p *poolManager[T]) Return(po *pooledObject[T]) bool {
+	if !po.claim() {
+		//return a object that not existed
+		return false
+	}
+	p.Release(po)
+	p.Deactivated()
+	return true
+}
+
+//Release make the deactivated object idle
+func (p *poolManager[T]) Release(po *pooledObject[T]) {
 	po.Returned()
+	p.push(po)
+}
+
+func (p *poolManager[T]) push(po *pooledObject[T]) {
+	if p.hot != nil {
+		p.hot.Push(po)
+		return
+	}
 	p.idle.Push(po)
 }
 
-func (p *poolManager) Deactivate(object interface{}) {
-	delete(p.active, object)
+//Deactivate make the active object not active, and report whether it's active. It could be called without lock.
+func (p *poolManager[T]) Deactivate(po *pooledObject[T]) bool {
+	if !po.claim() {
+		return false
+	}
+	p.Deactivated()
+	return true
+}
+
+//Deactivated count the object claimed by pooledObject.claim as not active.
+//The claimed object is still counted until it's idle or retired, so that a closed pool is not drained meanwhile.
+func (p *poolManager[T]) Deactivated() {
+	atomic.AddInt64(&p.active, -1)
 }
 
-func (p *poolManager) ActiveSize() int {
-	return len(p.active)
+//Remove remove the deactivated object from pool, it's unregistered after destroyed
+func (p *poolManager[T]) Remove(po *pooledObject[T]) {
+	p.addSize(-1)
 }
 
-func (p *poolManager) IdleSize() int {
-	return p.idle.Len()
+func (p *poolManager[T]) addSize(delta int) {
+	atomic.AddInt64(&p.size, int64(delta))
 }
 
-func (p *poolManager) Size() int {
-	return p.ActiveSize() + p.IdleSize()
+//Actives return all active objects
+func (p *poolManager[T]) Actives() []*pooledObject[T] {
+	actives := make([]*pooledObject[T], 0, p.ActiveSize())
+	for po := range p.objects {
+		if po.IsActive() {
+			actives = append(actives, po)
+		}
+	}
+	return actives
+}
+
+func (p *poolManager[T]) ActiveSize() int {
+	return int(atomic.LoadInt64(&p.active))
+}
+
+func (p *poolManager[T]) IdleSize() int {
+	size := p.idle.Len()
+	if p.hot != nil {
+		size += p.hot.Len()
+	}
+	return size
+}
+
+func (p *poolManager[T]) Size() int {
+	return int(atomic.LoadInt64(&p.size))
 }
 
-func (p *poolManager) RangeIdle(fn func(object interface{})) {
-	p.idle.Range(func(po *pooledObject) {
+func (p *poolManager[T]) RangeIdle(fn func(object T)) {
+	p.flush()
+	p.idle.Range(func(po *pooledObject[T]) {
 		fn(po.Object())
 	})
 }
 
-func (p *poolManager) RangeActive(fn func(object interface{})) {
-	for _, po := range p.active {
-		fn(po.Object())
+func (p *poolManager[T]) RangeActive(fn func(object T)) {
+	for po := range p.objects {
+		if po.IsActive() {
+			fn(po.Object())
+		}
 	}
 }
 
-func (p *poolManager) Range(fn func(object interface{})) {
+func (p *poolManager[T]) Range(fn func(object T)) {
 	p.RangeIdle(fn)
 	p.RangeActive(fn)
 }
+
+//isComparable report whether the object could be used as a key of index.
+//The dynamic values of interfaces are checked too, since comparing unhashable ones panics.
+func isComparable(object interface{}) bool {
+	return object == nil || isComparableValue(reflect.ValueOf(object))
+}
+
+func isComparableValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Interface:
+		return v.IsNil() || isComparableValue(v.Elem())
+	case reflect.Struct:
+		if !v.Type().Comparable() {
+			return false
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if !isComparableValue(v.Field(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Array:
+		if !v.Type().Comparable() {
+			return false
+		}
+		for i := 0; i < v.Len(); i++ {
+			if !isComparableValue(v.Index(i)) {
+				return false
+			}
+		}
+		return true
+	default:
+		return v.Type().Comparable()
+	}
+}
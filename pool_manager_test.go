@@ -8,7 +8,7 @@ import (
 )
 
 func TestPoolManager(t *testing.T) {
-	pm := newPoolManager()
+	pm := newPoolManager[interface{}](IdleOrderLIFO)
 	assert.Equal(t, 0, pm.ActiveSize())
 	assert.Equal(t, 0, pm.IdleSize())
 
@@ -27,7 +27,7 @@ func TestPoolManager(t *testing.T) {
 		obj := pobj.Object().(*testObject)
 		assert.Equal(t, strconv.Itoa(loopSize-1), obj.name)
 		assert.Equal(t, 1, pm.ActiveSize())
-		pm.Return(obj)
+		pm.Return(pobj)
 	}
 	assert.Equal(t, 0, pm.ActiveSize())
 	assert.Equal(t, loopSize, pm.IdleSize())
@@ -43,10 +43,54 @@ func TestPoolManager(t *testing.T) {
 	for i := 1; i <= loopSize; i++ {
 		pobj := pm.Borrow()
 		assert.Equal(t, 1, pm.ActiveSize())
-		pm.Deactivate(pobj.Object())
+		pm.Deactivate(pobj)
 		assert.Equal(t, 0, pm.ActiveSize())
 		assert.Equal(t, loopSize-i, pm.IdleSize())
 	}
 	assert.Equal(t, 0, pm.ActiveSize())
 	assert.Equal(t, 0, pm.IdleSize())
 }
+
+func TestPoolManagerTrack(t *testing.T) {
+	pm := newPoolManager[interface{}](IdleOrderLIFO)
+	type value struct {
+		n int
+	}
+	pm.Create(value{n: 1})
+	pm.Create(value{n: 1})
+	pm.Create([]int{1})
+
+	//value-equal objects share the same key
+	p0 := pm.Borrow()
+	p1, p2 := pm.Borrow(), pm.Borrow()
+	assert.Nil(t, pm.Lookup(value{n: 1}))
+	//only indexed when borrowed by value
+	_, indexed := pm.index.Load(value{n: 1})
+	assert.False(t, indexed)
+	for _, po := range []*pooledObject[interface{}]{p1, p2} {
+		pm.Index(po)
+		pm.Track(po)
+	}
+	assert.NotNil(t, pm.Lookup(value{n: 1}))
+	pm.Return(pm.Lookup(value{n: 1}))
+	assert.NotNil(t, pm.Lookup(value{n: 1}))
+	pm.Return(pm.Lookup(value{n: 1}))
+	assert.Nil(t, pm.Lookup(value{n: 1}))
+	//idle objects are still indexed, but not found until borrowed by value again
+	_, indexed = pm.index.Load(value{n: 1})
+	assert.True(t, indexed)
+	pm.Unregister(p1)
+	pm.Unregister(p2)
+	_, indexed = pm.index.Load(value{n: 1})
+	assert.False(t, indexed)
+
+	//non-comparable object is tracked by pooled object only
+	assert.False(t, isComparable(p0.Object()))
+	assert.False(t, isComparable(struct{ v interface{} }{[]int{1}}))
+	assert.True(t, isComparable(struct{ v interface{} }{1}))
+	assert.True(t, pm.IsActive(p0))
+	pm.Deactivate(p0)
+	assert.False(t, pm.IsActive(p0))
+	assert.Equal(t, 0, pm.ActiveSize())
+	assert.Equal(t, 2, pm.IdleSize())
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -43,6 +44,12 @@ var testObjectValidateFactory ObjectValidateFactory = func(ctx context.Context,
 	return cerr == nil
 }
 
+func waitersLen[T any](p *TypedPool[T]) int {
+	p.actionLock.RLock()
+	defer p.actionLock.RUnlock()
+	return p.waiters.Len()
+}
+
 func TestBasicPool(t *testing.T) {
 	ctx := context.Background()
 	cfg := NewConfig(testObjectCreateFactory)
@@ -142,7 +149,7 @@ func TestPoolEvictManually(t *testing.T) {
 	p.Evict(ctx)
 	assert.Equal(t, cfg.MaxIdle, p.manager.IdleSize())
 
-	latestPObj := p.manager.Latest().Object().(*testObject)
+	latestPObj := p.manager.Next().Object().(*testObject)
 	for i := 0; i < loopSize; i++ {
 		name := strconv.Itoa(i)
 		obj, err := p.BorrowObject(context.WithValue(ctx, contextKeyName{}, name))
@@ -269,6 +276,33 @@ func TestPoolValidateRetry(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestPoolValidateFailedUnregistered(t *testing.T) {
+	ctx := context.Background()
+	var created int32
+	cfg := NewConfig(func(ctx context.Context) (interface{}, error) {
+		return int(atomic.AddInt32(&created, 1)), nil
+	})
+	cfg.AutoEvict = false
+	cfg.MaxValidateAttempts = 2
+	//reject the odd objects
+	cfg.ObjectValidateFactory = func(ctx context.Context, object interface{}) bool {
+		return object.(int)%2 == 0
+	}
+	p, _ := New(cfg)
+	defer p.Close(ctx)
+
+	for i := 0; i < 100; i++ {
+		obj, err := p.BorrowObject(ctx)
+		assert.NoError(t, err)
+		assert.NoError(t, p.InvalidateObject(ctx, obj))
+	}
+	assert.Equal(t, 0, p.Size())
+	assert.Equal(t, 0, len(p.manager.objects))
+	assert.True(t, len(p.manager.hot.table.Load().([]*pooledObject[interface{}])) <= 3)
+	_, ok := p.manager.index.Load(1)
+	assert.False(t, ok)
+}
+
 func TestPoolNonblocking(t *testing.T) {
 	ctx := context.Background()
 	cfg := NewConfig(testObjectCreateFactory)
@@ -418,3 +452,640 @@ func TestPoolBorrowWhenAlwaysCreateFailed(t *testing.T) {
 		assert.Nil(t, obj)
 	}
 }
+
+func TestTypedPool(t *testing.T) {
+	ctx := context.Background()
+	cfg := NewTypedConfig(func(ctx context.Context) (*testObject, error) {
+		return &testObject{name: "typed"}, nil
+	})
+	destroyed := 0
+	cfg.ObjectDestroyFactory = func(ctx context.Context, object *testObject) error {
+		destroyed++
+		return nil
+	}
+	p, err := NewTyped(cfg)
+	assert.NoError(t, err)
+	for i := 0; i < loopSize; i++ {
+		obj, err := p.Borrow(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, "typed", obj.name)
+		err = p.Return(ctx, obj)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 1, p.Size())
+
+	obj, err := p.Borrow(ctx)
+	assert.NoError(t, err)
+	err = p.Invalidate(ctx, obj)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, destroyed)
+	assert.Equal(t, 0, p.Size())
+
+	_, err = NewTyped(NewTypedDefaultConfig[*testObject]())
+	assert.Equal(t, ErrObjectCreateFactoryNotFound, err)
+}
+
+func TestPoolFairWaiters(t *testing.T) {
+	ctx := context.Background()
+	cfg := NewConfig(testObjectCreateFactory)
+	cfg.MaxSize = 3
+	p, _ := New(cfg)
+	defer p.Close(ctx)
+
+	objs := make([]interface{}, 0)
+	for i := 0; i < cfg.MaxSize; i++ {
+		obj, err := p.BorrowObject(ctx)
+		assert.NoError(t, err)
+		objs = append(objs, obj)
+	}
+
+	//waiters are queued by order
+	waiters := 5
+	got := make([]chan interface{}, waiters)
+	for i := 0; i < waiters; i++ {
+		got[i] = make(chan interface{}, 1)
+		go func(n int) {
+			obj, err := p.BorrowObject(ctx)
+			assert.NoError(t, err)
+			got[n] <- obj
+		}(i)
+		time.Sleep(time.Millisecond * 10)
+	}
+	assert.Equal(t, waiters, waitersLen(p.TypedPool))
+
+	//return objects in quick succession, all of them should be handed off by order
+	for _, obj := range objs {
+		assert.NoError(t, p.ReturnObject(ctx, obj))
+	}
+	for i := 0; i < cfg.MaxSize; i++ {
+		assert.True(t, objs[i] == <-got[i])
+	}
+	assert.Equal(t, waiters-cfg.MaxSize, waitersLen(p.TypedPool))
+
+	//remaining waiters
+	for i := cfg.MaxSize; i < waiters; i++ {
+		assert.NoError(t, p.ReturnObject(ctx, objs[i-cfg.MaxSize]))
+		assert.True(t, objs[i-cfg.MaxSize] == <-got[i])
+	}
+	assert.Equal(t, 0, waitersLen(p.TypedPool))
+	assert.Equal(t, 0, p.IdleSize())
+}
+
+func TestPoolInvalidateWakeupWaiter(t *testing.T) {
+	ctx := context.Background()
+	cfg := NewConfig(testObjectCreateFactory)
+	cfg.MaxSize = 1
+	p, _ := New(cfg)
+	defer p.Close(ctx)
+
+	obj, err := p.BorrowObject(ctx)
+	assert.NoError(t, err)
+
+	//waiter canceled should leave the queue
+	cctx, cancel := context.WithTimeout(ctx, time.Millisecond*10)
+	defer cancel()
+	_, err = p.BorrowObject(cctx)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, 0, waitersLen(p.TypedPool))
+
+	done := make(chan interface{})
+	go func() {
+		obj, err := p.BorrowObject(ctx)
+		assert.NoError(t, err)
+		done <- obj
+	}()
+	time.Sleep(time.Millisecond * 10)
+	//the slot freed should be handed to waiter to create a new one
+	assert.NoError(t, p.InvalidateObject(ctx, obj))
+	newObj := <-done
+	assert.False(t, obj == newObj)
+	assert.Equal(t, 1, p.Size())
+}
+
+func TestPoolCreateWithoutLock(t *testing.T) {
+	ctx := context.Background()
+	delay := time.Millisecond * 200
+	cfg := NewConfig(func(ctx context.Context) (interface{}, error) {
+		time.Sleep(delay)
+		return &testObject{}, nil
+	})
+	cfg.MaxSize = 4
+	p, _ := New(cfg)
+	defer p.Close(ctx)
+
+	var wg sync.WaitGroup
+	begin := time.Now()
+	objs := make(chan interface{}, cfg.MaxSize*2)
+	for i := 0; i < cfg.MaxSize*2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cctx, cancel := context.WithTimeout(ctx, delay*3/2)
+			defer cancel()
+			obj, err := p.BorrowObject(cctx)
+			if err == nil {
+				objs <- obj
+			}
+		}()
+	}
+
+	//pool is not blocked when creating
+	time.Sleep(delay / 4)
+	sizeBegin := time.Now()
+	assert.Equal(t, 0, p.Size())
+	assert.True(t, time.Since(sizeBegin) < delay/4)
+
+	//objects are created in parallel, and MaxSize is never exceeded
+	wg.Wait()
+	close(objs)
+	assert.True(t, time.Since(begin) < delay*2)
+	assert.Equal(t, cfg.MaxSize, len(objs))
+	assert.Equal(t, cfg.MaxSize, p.Size())
+}
+
+func TestPoolIdleOrderFIFO(t *testing.T) {
+	ctx := context.Background()
+	cfg := NewConfig(testObjectCreateFactory)
+	cfg.IdleOrder = IdleOrderFIFO
+	p, _ := New(cfg)
+	defer p.Close(ctx)
+
+	objs := make([]interface{}, 0)
+	for i := 0; i < cfg.MaxSize; i++ {
+		obj, err := p.BorrowObject(context.WithValue(ctx, contextKeyName{}, strconv.Itoa(i)))
+		assert.NoError(t, err)
+		objs = append(objs, obj)
+	}
+	for _, obj := range objs {
+		assert.NoError(t, p.ReturnObject(ctx, obj))
+	}
+
+	//round-robin over all objects
+	for i := 0; i < loopSize; i++ {
+		obj, err := p.BorrowObject(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, strconv.Itoa(i%cfg.MaxSize), obj.(*testObject).name)
+		assert.NoError(t, p.ReturnObject(ctx, obj))
+	}
+}
+
+func TestPoolMaxLifetime(t *testing.T) {
+	ctx := context.Background()
+	cfg := NewConfig(testObjectCreateFactory)
+	cfg.MaxLifetime = time.Millisecond * 100
+	cfg.LifetimeJitter = time.Millisecond * 50
+	cfg.AutoEvict = false
+	destroyed := 0
+	cfg.ObjectDestroyFactory = func(ctx context.Context, object interface{}) error {
+		destroyed++
+		return nil
+	}
+	p, _ := New(cfg)
+	defer p.Close(ctx)
+
+	//destroyed when returned
+	obj, err := p.BorrowObject(ctx)
+	assert.NoError(t, err)
+	time.Sleep(cfg.MaxLifetime)
+	assert.NoError(t, p.ReturnObject(ctx, obj))
+	assert.Equal(t, 1, destroyed)
+	assert.Equal(t, 0, p.Size())
+
+	//skipped when borrowed
+	obj, err = p.BorrowObject(ctx)
+	assert.NoError(t, err)
+	assert.NoError(t, p.ReturnObject(ctx, obj))
+	time.Sleep(cfg.MaxLifetime)
+	newObj, err := p.BorrowObject(ctx)
+	assert.NoError(t, err)
+	assert.False(t, obj == newObj)
+	assert.Equal(t, 2, destroyed)
+	assert.Equal(t, 1, p.Size())
+
+	//evicted when idle
+	assert.NoError(t, p.ReturnObject(ctx, newObj))
+	time.Sleep(cfg.MaxLifetime)
+	assert.NoError(t, p.Evict(ctx))
+	assert.Equal(t, 3, destroyed)
+	assert.Equal(t, 0, p.Size())
+
+	//the jitter not less than MaxLifetime is clamped, objects still expire
+	p.config.MaxLifetime = time.Millisecond
+	p.config.LifetimeJitter = time.Hour
+	for i := 0; i < 50; i++ {
+		lifetime := p.lifetime()
+		assert.True(t, lifetime > 0 && lifetime <= p.config.MaxLifetime, "lifetime %v", lifetime)
+	}
+}
+
+func TestPoolMaxUses(t *testing.T) {
+	ctx := context.Background()
+	cfg := NewConfig(testObjectCreateFactory)
+	cfg.MaxUses = 3
+	destroyed := 0
+	cfg.ObjectDestroyFactory = func(ctx context.Context, object interface{}) error {
+		destroyed++
+		return nil
+	}
+	p, _ := New(cfg)
+	defer p.Close(ctx)
+
+	objs := make(map[interface{}]int)
+	for i := 0; i < cfg.MaxUses*10; i++ {
+		obj, err := p.BorrowObject(ctx)
+		assert.NoError(t, err)
+		objs[obj]++
+		assert.NoError(t, p.ReturnObject(ctx, obj))
+	}
+	assert.Equal(t, 10, len(objs))
+	for _, uses := range objs {
+		assert.Equal(t, cfg.MaxUses, uses)
+	}
+	assert.Equal(t, 10, destroyed)
+	assert.Equal(t, 0, p.Size())
+}
+
+func TestPoolValidateOnReturn(t *testing.T) {
+	ctx := context.Background()
+	cfg := NewConfig(testObjectCreateFactory)
+	cfg.MaxSize = 1
+	cfg.ValidateOnReturn = true
+	cfg.ObjectValidateFactory = func(ctx context.Context, object interface{}) bool {
+		return object.(*testObject).name != "broken"
+	}
+	destroyed := 0
+	cfg.ObjectDestroyFactory = func(ctx context.Context, object interface{}) error {
+		destroyed++
+		return nil
+	}
+	p, _ := New(cfg)
+	defer p.Close(ctx)
+
+	//valid object goes back to idle
+	obj, err := p.BorrowObject(ctx)
+	assert.NoError(t, err)
+	assert.NoError(t, p.ReturnObject(ctx, obj))
+	assert.Equal(t, 1, p.IdleSize())
+
+	//broken object is destroyed, and the waiter creates a new one
+	obj, err = p.BorrowObject(ctx)
+	assert.NoError(t, err)
+	done := make(chan interface{})
+	go func() {
+		obj, err := p.BorrowObject(context.WithValue(ctx, contextKeyName{}, "new"))
+		assert.NoError(t, err)
+		done <- obj
+	}()
+	time.Sleep(time.Millisecond * 10)
+	obj.(*testObject).name = "broken"
+	assert.NoError(t, p.ReturnObject(ctx, obj))
+	assert.Equal(t, 1, destroyed)
+	newObj := <-done
+	assert.Equal(t, "new", newObj.(*testObject).name)
+	assert.Equal(t, 1, p.Size())
+
+	//unknown or released objects are not validated
+	validates := p.Histograms().Validate.Count
+	failures := p.Stats().ValidateFailures
+	assert.NoError(t, p.ReturnObject(ctx, &testObject{name: "broken"}))
+	assert.NoError(t, p.ReturnObject(ctx, obj))
+	assert.Equal(t, validates, p.Histograms().Validate.Count)
+	assert.Equal(t, failures, p.Stats().ValidateFailures)
+	assert.Equal(t, 1, destroyed)
+}
+
+func TestPoolTestWhileIdle(t *testing.T) {
+	ctx := context.Background()
+	cfg := NewConfig(testObjectCreateFactory)
+	cfg.MinIdle = 5
+	cfg.AutoEvict = false
+	cfg.TestWhileIdle = true
+	cfg.TestsPerEvictRun = 3
+	var lock sync.Mutex
+	pinged := make(map[interface{}]int)
+	cfg.ObjectKeepaliveFactory = func(ctx context.Context, object interface{}) error {
+		lock.Lock()
+		defer lock.Unlock()
+		pinged[object]++
+		if object.(*testObject).name == "dead" {
+			return errors.New("connection reset")
+		}
+		return nil
+	}
+	destroyed := 0
+	cfg.ObjectDestroyFactory = func(ctx context.Context, object interface{}) error {
+		destroyed++
+		return nil
+	}
+	p, _ := New(cfg)
+	defer p.Close(ctx)
+
+	//warmup
+	assert.NoError(t, p.Evict(ctx))
+	assert.Equal(t, cfg.MinIdle, p.IdleSize())
+	assert.Equal(t, 0, len(pinged))
+
+	//test the earliest sample per run
+	assert.NoError(t, p.Evict(ctx))
+	assert.Equal(t, cfg.TestsPerEvictRun, len(pinged))
+	assert.Equal(t, cfg.MinIdle, p.IdleSize())
+	assert.Equal(t, 0, destroyed)
+
+	//dead objects are destroyed and replaced
+	p.manager.RangeIdle(func(object interface{}) {
+		object.(*testObject).name = "dead"
+	})
+	assert.NoError(t, p.Evict(ctx))
+	assert.Equal(t, cfg.TestsPerEvictRun, destroyed)
+	assert.Equal(t, cfg.MinIdle, p.IdleSize())
+
+	//test all
+	p.config.TestsPerEvictRun = 0
+	assert.NoError(t, p.Evict(ctx))
+	assert.Equal(t, cfg.MinIdle, destroyed)
+	assert.Equal(t, cfg.MinIdle, p.IdleSize())
+}
+
+func TestPoolTestWhileIdleRoundRobin(t *testing.T) {
+	ctx := context.Background()
+	cfg := NewConfig(testObjectCreateFactory)
+	cfg.MinIdle = 10
+	cfg.AutoEvict = false
+	cfg.TestWhileIdle = true
+	cfg.TestsPerEvictRun = 3
+	pinged := make(map[interface{}]int)
+	cfg.ObjectKeepaliveFactory = func(ctx context.Context, object interface{}) error {
+		pinged[object]++
+		return nil
+	}
+	p, _ := New(cfg)
+	defer p.Close(ctx)
+
+	//warmup
+	assert.NoError(t, p.Evict(ctx))
+	assert.Equal(t, cfg.MinIdle, p.IdleSize())
+
+	idle := func() []interface{} {
+		objects := make([]interface{}, 0)
+		p.manager.RangeIdle(func(object interface{}) {
+			objects = append(objects, object)
+		})
+		return objects
+	}
+	order := idle()
+
+	//every idle object is tested within ceil(idle/n) runs
+	runs := (cfg.MinIdle + cfg.TestsPerEvictRun - 1) / cfg.TestsPerEvictRun
+	for i := 0; i < runs-1; i++ {
+		assert.NoError(t, p.Evict(ctx))
+	}
+	assert.Equal(t, (runs-1)*cfg.TestsPerEvictRun, len(pinged))
+	assert.NoError(t, p.Evict(ctx))
+	assert.Equal(t, cfg.MinIdle, len(pinged))
+	for _, n := range pinged {
+		assert.True(t, n <= 2)
+	}
+	//the tested objects are restored by the order returned
+	assert.Equal(t, order, idle())
+}
+
+func TestPoolAbandoned(t *testing.T) {
+	ctx := context.Background()
+	cfg := NewConfig(testObjectCreateFactory)
+	cfg.MaxSize = 2
+	cfg.AutoEvict = false
+	cfg.AbandonedTimeout = time.Millisecond * 50
+	cfg.AbandonedStackTrace = true
+	abandoned := make([]AbandonedObject, 0)
+	cfg.OnAbandoned = func(ctx context.Context, a AbandonedObject) {
+		abandoned = append(abandoned, a)
+	}
+	destroyed := 0
+	cfg.ObjectDestroyFactory = func(ctx context.Context, object interface{}) error {
+		destroyed++
+		return nil
+	}
+	p, _ := New(cfg)
+	defer p.Close(ctx)
+
+	//report only
+	obj, err := p.BorrowObject(ctx)
+	assert.NoError(t, err)
+	assert.NoError(t, p.Evict(ctx))
+	assert.Equal(t, 0, len(abandoned))
+	time.Sleep(cfg.AbandonedTimeout)
+	assert.NoError(t, p.Evict(ctx))
+	assert.Equal(t, 1, len(abandoned))
+	assert.True(t, obj == abandoned[0].Object)
+	assert.False(t, abandoned[0].Removed)
+	assert.Contains(t, string(abandoned[0].Stack), "TestPoolAbandoned")
+	//reported once
+	assert.NoError(t, p.Evict(ctx))
+	assert.Equal(t, 1, len(abandoned))
+	assert.NoError(t, p.ReturnObject(ctx, obj))
+
+	//reclaim
+	p.config.RemoveAbandoned = true
+	lease, err := p.BorrowHandle(ctx)
+	assert.NoError(t, err)
+	time.Sleep(cfg.AbandonedTimeout)
+	assert.NoError(t, p.Evict(ctx))
+	assert.Equal(t, 2, len(abandoned))
+	assert.True(t, abandoned[1].Removed)
+	assert.Equal(t, 1, destroyed)
+	assert.Equal(t, 0, p.Size())
+	//release after reclaimed
+	assert.NoError(t, lease.Release(ctx))
+	assert.Equal(t, 0, p.Size())
+	assert.Equal(t, 1, destroyed)
+}
+
+func TestPoolAbandonedEnabledAtRuntime(t *testing.T) {
+	ctx := context.Background()
+	cfg := NewConfig(testObjectCreateFactory)
+	cfg.AutoEvict = false
+	abandoned := 0
+	cfg.OnAbandoned = func(ctx context.Context, a AbandonedObject) {
+		abandoned++
+	}
+	p, _ := New(cfg)
+	defer p.Close(ctx)
+
+	//the borrowing time is not recorded while disabled
+	obj, err := p.BorrowObject(ctx)
+	assert.NoError(t, err)
+	time.Sleep(time.Millisecond * 50)
+	assert.NoError(t, p.UpdateConfig(func(config *Config) {
+		config.AbandonedTimeout = time.Millisecond * 50
+	}))
+	//counted since enabled
+	assert.NoError(t, p.Evict(ctx))
+	assert.Equal(t, 0, abandoned)
+	time.Sleep(time.Millisecond * 50)
+	assert.NoError(t, p.Evict(ctx))
+	assert.Equal(t, 1, abandoned)
+	assert.NoError(t, p.ReturnObject(ctx, obj))
+}
+
+func TestPoolShutdown(t *testing.T) {
+	ctx := context.Background()
+	cfg := NewConfig(testObjectCreateFactory)
+	cfg.EvictInterval = time.Millisecond
+	p, _ := New(cfg)
+
+	obj1, _ := p.BorrowObject(ctx)
+	obj2, _ := p.BorrowObject(ctx)
+	obj3, _ := p.BorrowObject(ctx)
+	assert.NoError(t, p.ReturnObject(ctx, obj3))
+
+	//return in time
+	go func() {
+		time.Sleep(time.Millisecond * 10)
+		assert.NoError(t, p.ReturnObject(ctx, obj1))
+		assert.NoError(t, p.ReturnObject(ctx, obj2))
+	}()
+	forced, err := p.Shutdown(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, forced)
+	assert.Equal(t, 0, p.Size())
+	assert.Equal(t, int64(3), p.Stats().Destroys)
+
+	_, err = p.BorrowObject(ctx)
+	assert.Equal(t, ErrPoolClosed, err)
+	forced, err = p.Shutdown(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, forced)
+}
+
+func TestPoolShutdownForcibly(t *testing.T) {
+	ctx := context.Background()
+	cfg := NewConfig(testObjectCreateFactory)
+	p, _ := New(cfg)
+
+	obj1, _ := p.BorrowObject(ctx)
+	lease, _ := p.BorrowHandle(ctx)
+	obj3, _ := p.BorrowObject(ctx)
+	assert.NoError(t, p.ReturnObject(ctx, obj3))
+	assert.NoError(t, p.Close(ctx))
+
+	cctx, cancel := context.WithTimeout(ctx, time.Millisecond*10)
+	defer cancel()
+	forced, err := p.Shutdown(cctx)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, 2, forced)
+	assert.Equal(t, 0, p.Size())
+	assert.Equal(t, int64(3), p.Stats().Destroys)
+
+	//stragglers returned after shutdown are not destroyed again
+	assert.NoError(t, p.ReturnObject(ctx, obj1))
+	assert.NoError(t, lease.Release(ctx))
+	assert.Equal(t, int64(3), p.Stats().Destroys)
+}
+
+func TestPoolLockless(t *testing.T) {
+	ctx := context.Background()
+	cfg := NewConfig(testObjectCreateFactory)
+	cfg.AutoEvict = false
+	p, _ := New(cfg)
+	obj, _ := p.BorrowObject(ctx)
+	assert.NoError(t, p.ReturnObject(ctx, obj))
+
+	//the idle object is borrowed and returned while the lock is held by others
+	p.actionLock.Lock()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		o, err := p.BorrowObject(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, obj, o)
+		assert.NoError(t, p.ReturnObject(ctx, o))
+		lease, err := p.BorrowHandle(ctx)
+		assert.NoError(t, err)
+		assert.NoError(t, lease.Release(ctx))
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("borrowing idle object is blocked by lock")
+	}
+	p.actionLock.Unlock()
+	<-done
+	assert.Equal(t, 1, p.IdleSize())
+	assert.Equal(t, int64(3), p.Stats().Borrows)
+	assert.Equal(t, int64(3), p.Stats().Returns)
+
+	//evictor sees the objects returned without lock in order
+	assert.Equal(t, obj, p.manager.Next().Object())
+	assert.NoError(t, p.Close(ctx))
+	assert.Equal(t, 0, p.Size())
+}
+
+func TestPoolLocklessConcurrent(t *testing.T) {
+	ctx := context.Background()
+	cfg := NewConfig(testObjectCreateFactory)
+	cfg.MaxSize = 4
+	cfg.MaxIdle = 2
+	cfg.MinIdleTime = 0
+	cfg.MaxUses = 50
+	cfg.AutoEvict = false
+	p, _ := New(cfg)
+
+	stopCh := make(chan struct{})
+	evicted := make(chan struct{})
+	go func() {
+		defer close(evicted)
+		for i := 0; ; i++ {
+			select {
+			case <-stopCh:
+				return
+			case <-time.After(time.Millisecond):
+				_ = p.Evict(ctx)
+				_ = p.SetMaxSize(2 + i%3)
+			}
+		}
+	}()
+
+	//every object is owned by one borrower at most
+	var owners sync.Map
+	own := func(obj interface{}) {
+		_, owned := owners.LoadOrStore(obj, struct{}{})
+		assert.False(t, owned)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 300; j++ {
+				if i%2 == 0 {
+					obj, err := p.BorrowObject(ctx)
+					assert.NoError(t, err)
+					own(obj)
+					owners.Delete(obj)
+					assert.NoError(t, p.ReturnObject(ctx, obj))
+					continue
+				}
+				lease, err := p.BorrowHandle(ctx)
+				assert.NoError(t, err)
+				own(lease.Object())
+				owners.Delete(lease.Object())
+				assert.NoError(t, lease.Release(ctx))
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(stopCh)
+	<-evicted
+
+	assert.Equal(t, 0, p.ActiveSize())
+	assert.Equal(t, p.Size(), p.IdleSize())
+	assert.True(t, p.Size() <= 4)
+	forced, err := p.Shutdown(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, forced)
+	stats := p.Stats()
+	assert.Equal(t, int64(2400), stats.Borrows)
+	assert.Equal(t, int64(2400), stats.Returns)
+	assert.Equal(t, stats.Creates, stats.Destroys)
+	assert.Equal(t, 0, p.Size())
+}
@@ -1,29 +1,127 @@
 package pond
 
 import (
+	"sync/atomic"
 	"time"
 )
 
-type pooledObject struct {
-	object   interface{}
+const (
+	//stateIdle is idle, or retired
+	stateIdle int32 = iota
+	//stateActive is borrowed by handle
+	stateActive
+	//stateTracked is borrowed by value, so it could be found by value when returned
+	stateTracked
+)
+
+type pooledObject[T any] struct {
+	object   T
+	createAt time.Time
 	returnAt time.Time
+	expireAt time.Time //zero means never expired
+	state    int32     //changed atomically, so that it could be borrowed and returned without lock
+	id       uint32    //id in idleStack
+	next     uint32    //id of the next object in idleStack
+
+	borrowCount int
+	borrowAt    int64  //unix nano, read by evictor while borrowing without lock
+	stack       []byte //stack trace of the borrower
+	abandoned   int32  //reported as abandoned
+	indexed     int32  //indexed by value in poolManager, changed with lock but read atomically
 }
 
-func newPooledObject(object interface{}) *pooledObject {
-	return &pooledObject{
+func newPooledObject[T any](object T) *pooledObject[T] {
+	now := time.Now()
+	return &pooledObject[T]{
 		object:   object,
-		returnAt: time.Now(),
+		createAt: now,
+		returnAt: now,
 	}
 }
 
-func (o pooledObject) Object() interface{} {
+func (o *pooledObject[T]) Object() T {
 	return o.object
 }
 
-func (o pooledObject) IdleTime() time.Duration {
+func (o *pooledObject[T]) IdleTime() time.Duration {
 	return time.Since(o.returnAt)
 }
 
-func (o *pooledObject) Returned() {
+func (o *pooledObject[T]) Returned() {
 	o.returnAt = time.Now()
 }
+
+//returnedBefore report whether the object is returned before other
+func (o *pooledObject[T]) returnedBefore(other *pooledObject[T]) bool {
+	return o.returnAt.Before(other.returnAt)
+}
+
+//ReturnAt is the time returned
+func (o *pooledObject[T]) ReturnAt() time.Time {
+	return o.returnAt
+}
+
+//Borrowed count the borrowing. If stamp, the time is recorded for detecting abandoned objects.
+func (o *pooledObject[T]) Borrowed(stamp bool) {
+	o.borrowCount++
+	if stamp {
+		o.Stamp(time.Now())
+	}
+	atomic.StoreInt32(&o.abandoned, 0)
+}
+
+//Stamp set the time borrowed
+func (o *pooledObject[T]) Stamp(now time.Time) {
+	atomic.StoreInt64(&o.borrowAt, now.UnixNano())
+}
+
+//IsActive report whether the object is borrowed
+func (o *pooledObject[T]) IsActive() bool {
+	return atomic.LoadInt32(&o.state) != stateIdle
+}
+
+//claim change the state from active to idle, and report whether it's active before
+func (o *pooledObject[T]) claim() bool {
+	for {
+		state := atomic.LoadInt32(&o.state)
+		if state == stateIdle {
+			return false
+		}
+		if atomic.CompareAndSwapInt32(&o.state, state, stateIdle) {
+			return true
+		}
+	}
+}
+
+//BorrowAt is the time borrowed
+func (o *pooledObject[T]) BorrowAt() time.Time {
+	return time.Unix(0, atomic.LoadInt64(&o.borrowAt))
+}
+
+//BorrowTime is the duration since borrowed
+func (o *pooledObject[T]) BorrowTime() time.Duration {
+	return time.Since(o.BorrowAt())
+}
+
+//BorrowCount is how many times the object has been borrowed
+func (o *pooledObject[T]) BorrowCount() int {
+	return o.borrowCount
+}
+
+//Lifetime is the duration since created
+func (o *pooledObject[T]) Lifetime() time.Duration {
+	return time.Since(o.createAt)
+}
+
+//ExpireAfter set the maximal lifetime. If lifetime <= 0, it will never expire.
+func (o *pooledObject[T]) ExpireAfter(lifetime time.Duration) {
+	if lifetime <= 0 {
+		o.expireAt = time.Time{}
+		return
+	}
+	o.expireAt = o.createAt.Add(lifetime)
+}
+
+func (o *pooledObject[T]) Expired() bool {
+	return !o.expireAt.IsZero() && !time.Now().Before(o.expireAt)
+}
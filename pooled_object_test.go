@@ -16,3 +16,15 @@ func TestPooledObject(t *testing.T) {
 	end := po.returnAt
 	assert.Equal(t, end.Sub(beg).Milliseconds(), idleTime.Milliseconds())
 }
+
+func TestPooledObjectExpire(t *testing.T) {
+	po := newPooledObject(&testObject{name: "test"})
+	assert.False(t, po.Expired())
+	po.ExpireAfter(time.Millisecond * 50)
+	assert.False(t, po.Expired())
+	time.Sleep(time.Millisecond * 50)
+	assert.True(t, po.Expired())
+	assert.True(t, po.Lifetime() >= time.Millisecond*50)
+	po.ExpireAfter(0)
+	assert.False(t, po.Expired())
+}
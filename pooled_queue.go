@@ -0,0 +1,55 @@
+package pond
+
+//FIFO
+type pooledQueue[T any] struct {
+	ring *pooledRing[T]
+}
+
+func newPooledQueue[T any]() *pooledQueue[T] {
+	return &pooledQueue[T]{
+		ring: newPooledRing[T](),
+	}
+}
+
+func (p pooledQueue[T]) Len() int {
+	return p.ring.Len()
+}
+
+func (p *pooledQueue[T]) Push(po *pooledObject[T]) {
+	p.ring.PushBack(po)
+}
+
+//Pop pop head item
+func (p *pooledQueue[T]) Pop() *pooledObject[T] {
+	return p.ring.PopFront()
+}
+
+func (p *pooledQueue[T]) Top() *pooledObject[T] {
+	return p.ring.Front()
+}
+
+//BPush push item to bottom
+func (p *pooledQueue[T]) BPush(po *pooledObject[T]) {
+	p.ring.PushFront(po)
+}
+
+//BPop pop head item, which is also the earliest
+func (p *pooledQueue[T]) BPop() *pooledObject[T] {
+	return p.ring.PopFront()
+}
+
+func (p pooledQueue[T]) Bottom() *pooledObject[T] {
+	return p.ring.Front()
+}
+
+func (p *pooledQueue[T]) Restore(po *pooledObject[T]) {
+	p.ring.InsertBefore(po, po.returnedBefore)
+}
+
+func (p pooledQueue[T]) Range(handler func(object *pooledObject[T])) {
+	p.ring.Range(handler)
+}
+
+func (p *pooledQueue[T]) RemoveIf(match func(object *pooledObject[T]) bool) []*pooledObject[T] {
+	return p.ring.RemoveIf(match)
+}
@@ -0,0 +1,41 @@
+package pond
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPooledQueue(t *testing.T) {
+	q := newPooledQueue[interface{}]()
+	size := 100
+	assert.Equal(t, 0, q.Len())
+
+	//test pop
+	for i := 0; i < size; i++ {
+		q.Push(newPooledObject[interface{}](&testObject{name: strconv.Itoa(i)}))
+	}
+	assert.Equal(t, size, q.Len())
+	for i := 0; i < size; i++ {
+		top := q.Top()
+		assert.Equal(t, top, q.Bottom())
+		pop := q.Pop()
+		assert.Equal(t, top, pop)
+		assert.Equal(t, strconv.Itoa(i), pop.Object().(*testObject).name)
+		assert.Equal(t, size-i-1, q.Len())
+	}
+	assert.Equal(t, 0, q.Len())
+	assert.Nil(t, q.Pop())
+
+	//test bpop
+	for i := 0; i < size; i++ {
+		q.Push(newPooledObject[interface{}](&testObject{name: strconv.Itoa(i)}))
+	}
+	for i := 0; i < size; i++ {
+		pop := q.BPop()
+		assert.Equal(t, strconv.Itoa(i), pop.Object().(*testObject).name)
+	}
+	assert.Equal(t, 0, q.Len())
+	assert.Nil(t, q.BPop())
+}
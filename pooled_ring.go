@@ -0,0 +1,144 @@
+package pond
+
+const minRingCapacity = 8
+
+//pooledRing is a double-ended queue based on a growable ring buffer.
+//Popped slots are cleared, and the buffer shrinks when it's sparse, so it never leaks memory.
+type pooledRing[T any] struct {
+	buf  []*pooledObject[T]
+	head int
+	size int
+}
+
+func newPooledRing[T any]() *pooledRing[T] {
+	return &pooledRing[T]{
+		buf: make([]*pooledObject[T], minRingCapacity),
+	}
+}
+
+func (r pooledRing[T]) Len() int {
+	return r.size
+}
+
+func (r pooledRing[T]) index(i int) int {
+	return (r.head + i) % len(r.buf)
+}
+
+func (r *pooledRing[T]) resize(capacity int) {
+	buf := make([]*pooledObject[T], capacity)
+	for i := 0; i < r.size; i++ {
+		buf[i] = r.buf[r.index(i)]
+	}
+	r.buf = buf
+	r.head = 0
+}
+
+func (r *pooledRing[T]) shrink() {
+	if len(r.buf) > minRingCapacity && r.size <= len(r.buf)/4 {
+		r.resize(len(r.buf) / 2)
+	}
+}
+
+func (r *pooledRing[T]) PushBack(po *pooledObject[T]) {
+	if r.size == len(r.buf) {
+		r.resize(len(r.buf) * 2)
+	}
+	r.buf[r.index(r.size)] = po
+	r.size++
+}
+
+func (r *pooledRing[T]) PushFront(po *pooledObject[T]) {
+	if r.size == len(r.buf) {
+		r.resize(len(r.buf) * 2)
+	}
+	r.head = r.index(len(r.buf) - 1)
+	r.buf[r.head] = po
+	r.size++
+}
+
+//InsertBefore insert the item before the first item matched from front, or push it back if none matched
+func (r *pooledRing[T]) InsertBefore(po *pooledObject[T], match func(object *pooledObject[T]) bool) {
+	if r.size == len(r.buf) {
+		r.resize(len(r.buf) * 2)
+	}
+	i := 0
+	for i < r.size && !match(r.buf[r.index(i)]) {
+		i++
+	}
+	for j := r.size; j > i; j-- {
+		r.buf[r.index(j)] = r.buf[r.index(j-1)]
+	}
+	r.buf[r.index(i)] = po
+	r.size++
+}
+
+func (r *pooledRing[T]) PopBack() *pooledObject[T] {
+	if r.size == 0 {
+		return nil
+	}
+	i := r.index(r.size - 1)
+	po := r.buf[i]
+	r.buf[i] = nil
+	r.size--
+	r.shrink()
+	return po
+}
+
+func (r *pooledRing[T]) PopFront() *pooledObject[T] {
+	if r.size == 0 {
+		return nil
+	}
+	po := r.buf[r.head]
+	r.buf[r.head] = nil
+	r.head = r.index(1)
+	r.size--
+	r.shrink()
+	return po
+}
+
+func (r pooledRing[T]) Back() *pooledObject[T] {
+	if r.size == 0 {
+		return nil
+	}
+	return r.buf[r.index(r.size-1)]
+}
+
+func (r pooledRing[T]) Front() *pooledObject[T] {
+	if r.size == 0 {
+		return nil
+	}
+	return r.buf[r.head]
+}
+
+//Range iterate from front to back
+func (r pooledRing[T]) Range(handler func(object *pooledObject[T])) {
+	for i := 0; i < r.size; i++ {
+		handler(r.buf[r.index(i)])
+	}
+}
+
+//RemoveIf remove all items matched, and return them by order
+func (r *pooledRing[T]) RemoveIf(match func(object *pooledObject[T]) bool) []*pooledObject[T] {
+	var removed []*pooledObject[T]
+	n := 0
+	for i := 0; i < r.size; i++ {
+		po := r.buf[r.index(i)]
+		if match(po) {
+			removed = append(removed, po)
+			continue
+		}
+		if n != i {
+			r.buf[r.index(n)] = po
+		}
+		n++
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+	for i := n; i < r.size; i++ {
+		r.buf[r.index(i)] = nil
+	}
+	r.size = n
+	r.shrink()
+	return removed
+}
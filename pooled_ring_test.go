@@ -0,0 +1,110 @@
+package pond
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPooledRing(t *testing.T) {
+	r := newPooledRing[interface{}]()
+	size := 100
+	assert.Equal(t, 0, r.Len())
+	assert.Nil(t, r.Front())
+	assert.Nil(t, r.Back())
+
+	//wrap around
+	for round := 0; round < 3; round++ {
+		for i := 0; i < minRingCapacity/2; i++ {
+			r.PushBack(newPooledObject[interface{}](&testObject{name: strconv.Itoa(i)}))
+		}
+		for i := 0; i < minRingCapacity/2; i++ {
+			assert.Equal(t, strconv.Itoa(i), r.PopFront().Object().(*testObject).name)
+		}
+	}
+	assert.Equal(t, minRingCapacity, len(r.buf))
+
+	//grow
+	for i := 0; i < size; i++ {
+		r.PushBack(newPooledObject[interface{}](&testObject{name: strconv.Itoa(i)}))
+	}
+	assert.Equal(t, size, r.Len())
+	assert.True(t, len(r.buf) >= size)
+	n := 0
+	r.Range(func(po *pooledObject[interface{}]) {
+		assert.Equal(t, strconv.Itoa(n), po.Object().(*testObject).name)
+		n++
+	})
+	assert.Equal(t, size, n)
+
+	//shrink and never leak popped items
+	for i := 0; i < size/2; i++ {
+		assert.Equal(t, r.Front(), r.PopFront())
+		assert.Equal(t, r.Back(), r.PopBack())
+	}
+	assert.Equal(t, 0, r.Len())
+	assert.Equal(t, minRingCapacity, len(r.buf))
+	for _, po := range r.buf {
+		assert.Nil(t, po)
+	}
+	assert.Nil(t, r.PopFront())
+	assert.Nil(t, r.PopBack())
+}
+
+func TestPooledRingRemoveIf(t *testing.T) {
+	r := newPooledRing[interface{}]()
+	size := 100
+	for i := 0; i < size; i++ {
+		r.PushBack(newPooledObject[interface{}](&testObject{name: strconv.Itoa(i)}))
+	}
+	//remove odd items
+	removed := r.RemoveIf(func(po *pooledObject[interface{}]) bool {
+		n, _ := strconv.Atoi(po.Object().(*testObject).name)
+		return n%2 == 1
+	})
+	assert.Equal(t, size/2, len(removed))
+	assert.Equal(t, size/2, r.Len())
+	for i := 0; i < size/2; i++ {
+		assert.Equal(t, strconv.Itoa(i*2+1), removed[i].Object().(*testObject).name)
+		assert.Equal(t, strconv.Itoa(i*2), r.PopFront().Object().(*testObject).name)
+	}
+	for _, po := range r.buf {
+		assert.Nil(t, po)
+	}
+}
+
+func TestPooledRingPushFront(t *testing.T) {
+	r := newPooledRing[interface{}]()
+	size := 100
+	for i := 0; i < size; i++ {
+		r.PushFront(newPooledObject[interface{}](&testObject{name: strconv.Itoa(i)}))
+	}
+	assert.Equal(t, size, r.Len())
+	for i := size - 1; i >= 0; i-- {
+		assert.Equal(t, strconv.Itoa(i), r.PopFront().Object().(*testObject).name)
+	}
+	assert.Equal(t, 0, r.Len())
+}
+
+func TestPooledRingInsertBefore(t *testing.T) {
+	r := newPooledRing[interface{}]()
+	size := 100
+	for i := 0; i < size; i += 2 {
+		r.PushBack(newPooledObject[interface{}](&testObject{name: strconv.Itoa(i)}))
+	}
+	r.PopFront()
+	r.PushBack(newPooledObject[interface{}](&testObject{name: strconv.Itoa(size)}))
+	for i := 1; i < size; i += 2 {
+		n := i
+		r.InsertBefore(newPooledObject[interface{}](&testObject{name: strconv.Itoa(i)}), func(po *pooledObject[interface{}]) bool {
+			v, _ := strconv.Atoi(po.Object().(*testObject).name)
+			return v > n
+		})
+	}
+	assert.Equal(t, size, r.Len())
+	for i := 1; i <= size; i++ {
+		assert.Equal(t, strconv.Itoa(i), r.PopFront().Object().(*testObject).name)
+	}
+	assert.Equal(t, 0, r.Len())
+}
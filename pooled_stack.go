@@ -1,62 +1,55 @@
 package pond
 
 //LIFO
-type pooledStack struct {
-	stack []*pooledObject
+type pooledStack[T any] struct {
+	ring *pooledRing[T]
 }
 
-func newPooledStack() *pooledStack {
-	return &pooledStack{
-		stack: make([]*pooledObject, 0),
+func newPooledStack[T any]() *pooledStack[T] {
+	return &pooledStack[T]{
+		ring: newPooledRing[T](),
 	}
 }
 
-func (p pooledStack) Len() int {
-	return len(p.stack)
+func (p pooledStack[T]) Len() int {
+	return p.ring.Len()
 }
 
-func (p *pooledStack) Push(po *pooledObject) {
-	p.stack = append(p.stack, po)
+func (p *pooledStack[T]) Push(po *pooledObject[T]) {
+	p.ring.PushBack(po)
 }
 
 //Pop pop top item
-func (p *pooledStack) Pop() *pooledObject {
-	n := p.Len() - 1
-	if n < 0 {
-		return nil
-	}
-	po := p.stack[n]
-	p.stack = p.stack[:n]
-	return po
+func (p *pooledStack[T]) Pop() *pooledObject[T] {
+	return p.ring.PopBack()
 }
 
-func (p *pooledStack) Top() *pooledObject {
-	n := p.Len() - 1
-	if n < 0 {
-		return nil
-	}
-	return p.stack[n]
+func (p *pooledStack[T]) Top() *pooledObject[T] {
+	return p.ring.Back()
+}
+
+//BPush push item to bottom
+func (p *pooledStack[T]) BPush(po *pooledObject[T]) {
+	p.ring.PushFront(po)
 }
 
 //BPop pop bottom item
-func (p *pooledStack) BPop() *pooledObject {
-	if p.Len() <= 0 {
-		return nil
-	}
-	po := p.stack[0]
-	p.stack = p.stack[1:]
-	return po
+func (p *pooledStack[T]) BPop() *pooledObject[T] {
+	return p.ring.PopFront()
 }
 
-func (p pooledStack) Bottom() *pooledObject {
-	if p.Len() == 0 {
-		return nil
-	}
-	return p.stack[0]
+func (p pooledStack[T]) Bottom() *pooledObject[T] {
+	return p.ring.Front()
 }
 
-func (p pooledStack) Range(handler func(object *pooledObject)) {
-	for _, o := range p.stack {
-		handler(o)
-	}
+func (p *pooledStack[T]) Restore(po *pooledObject[T]) {
+	p.ring.InsertBefore(po, po.returnedBefore)
+}
+
+func (p pooledStack[T]) Range(handler func(object *pooledObject[T])) {
+	p.ring.Range(handler)
+}
+
+func (p *pooledStack[T]) RemoveIf(match func(object *pooledObject[T]) bool) []*pooledObject[T] {
+	return p.ring.RemoveIf(match)
 }
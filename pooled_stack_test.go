@@ -7,13 +7,13 @@ import (
 )
 
 func TestPooledStack(t *testing.T) {
-	stk := newPooledStack()
+	stk := newPooledStack[interface{}]()
 	size := 100
 	assert.Equal(t, 0, stk.Len())
 
 	//test pop
 	for i := 0; i < size; i++ {
-		stk.Push(newPooledObject(&testObject{name: strconv.Itoa(i)}))
+		stk.Push(newPooledObject[interface{}](&testObject{name: strconv.Itoa(i)}))
 	}
 	assert.Equal(t, size, stk.Len())
 	for i := 0; i < size; i++ {
@@ -29,7 +29,7 @@ func TestPooledStack(t *testing.T) {
 
 	//test bpop
 	for i := 0; i < size; i++ {
-		stk.Push(newPooledObject(&testObject{name: strconv.Itoa(i)}))
+		stk.Push(newPooledObject[interface{}](&testObject{name: strconv.Itoa(i)}))
 	}
 	assert.Equal(t, size, stk.Len())
 	for i := 0; i < size; i++ {
@@ -0,0 +1,185 @@
+//Package prometheus export pond pool statistics as prometheus metrics
+package prometheus
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/joway/pond"
+	prom "github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	DefaultNamespace = "pond"
+	poolLabel        = "pool"
+)
+
+var (
+	ErrPoolRegistered = errors.New("pool has been registered")
+)
+
+//StatsProvider is the pool to be collected, e.g. *pond.Pool and *pond.TypedPool[T]
+type StatsProvider interface {
+	Stats() pond.PoolStats
+	Histograms() pond.PoolHistograms
+}
+
+type gaugeMetric struct {
+	desc  *prom.Desc
+	value func(stats pond.PoolStats) float64
+}
+
+type counterMetric struct {
+	desc  *prom.Desc
+	value func(stats pond.PoolStats) float64
+}
+
+type histogramMetric struct {
+	desc  *prom.Desc
+	value func(histograms pond.PoolHistograms) pond.Histogram
+}
+
+//Collector implements prometheus.Collector for pools, labelled by pool name
+type Collector struct {
+	lock  sync.RWMutex
+	pools map[string]StatsProvider
+
+	gauges     []gaugeMetric
+	counters   []counterMetric
+	histograms []histogramMetric
+}
+
+//NewCollector create a collector with metrics prefixed by namespace. If namespace is empty, DefaultNamespace is used.
+func NewCollector(namespace string) *Collector {
+	if namespace == "" {
+		namespace = DefaultNamespace
+	}
+	desc := func(name, help string) *prom.Desc {
+		return prom.NewDesc(prom.BuildFQName(namespace, "pool", name), help, []string{poolLabel}, nil)
+	}
+	return &Collector{
+		pools: make(map[string]StatsProvider),
+		gauges: []gaugeMetric{
+			{desc("idle_objects", "The current number of idle objects."), func(s pond.PoolStats) float64 { return float64(s.Idle) }},
+			{desc("active_objects", "The current number of active objects."), func(s pond.PoolStats) float64 { return float64(s.Active) }},
+			{desc("objects", "The current number of objects, both idle and active."), func(s pond.PoolStats) float64 { return float64(s.Idle + s.Active) }},
+			{desc("waiters", "The current number of blocked borrowers."), func(s pond.PoolStats) float64 { return float64(s.Waiters) }},
+			{desc("max_size", "The capacity of the pool, 0 means no limit."), func(s pond.PoolStats) float64 { return float64(limit(s.MaxSize)) }},
+			{desc("min_idle", "The minimum size of the idle objects."), func(s pond.PoolStats) float64 { return float64(s.MinIdle) }},
+			{desc("max_idle", "The maximal size of the idle objects."), func(s pond.PoolStats) float64 { return float64(s.MaxIdle) }},
+		},
+		counters: []counterMetric{
+			{desc("borrows_total", "The number of objects borrowed successfully."), func(s pond.PoolStats) float64 { return float64(s.Borrows) }},
+			{desc("returns_total", "The number of objects returned."), func(s pond.PoolStats) float64 { return float64(s.Returns) }},
+			{desc("creates_total", "The number of objects created."), func(s pond.PoolStats) float64 { return float64(s.Creates) }},
+			{desc("create_failures_total", "The number of errors returned by ObjectCreateFactory."), func(s pond.PoolStats) float64 { return float64(s.CreateFailures) }},
+			{desc("destroys_total", "The number of objects destroyed."), func(s pond.PoolStats) float64 { return float64(s.Destroys) }},
+			{desc("validate_failures_total", "The number of objects failed to validate."), func(s pond.PoolStats) float64 { return float64(s.ValidateFailures) }},
+			{desc("evictions_total", "The number of idle objects evicted."), func(s pond.PoolStats) float64 { return float64(s.Evictions) }},
+			{desc("abandoned_total", "The number of objects detected as abandoned."), func(s pond.PoolStats) float64 { return float64(s.Abandoned) }},
+			{desc("invalidations_total", "The number of objects invalidated by borrower."), func(s pond.PoolStats) float64 { return float64(s.Invalidations) }},
+			{desc("borrow_waits_total", "The number of times that borrower waited for a object."), func(s pond.PoolStats) float64 { return float64(s.BorrowWaits) }},
+			{desc("borrow_timeouts_total", "The number of waits ended by context canceled or deadline exceeded."), func(s pond.PoolStats) float64 { return float64(s.BorrowTimeouts) }},
+			{desc("exhausted_total", "The number of borrows rejected by ErrPoolExhausted."), func(s pond.PoolStats) float64 { return float64(s.Exhausted) }},
+			{desc("borrow_wait_seconds_total", "The total duration that borrowers waited."), func(s pond.PoolStats) float64 { return s.WaitDuration.Seconds() }},
+		},
+		histograms: []histogramMetric{
+			{desc("borrow_wait_seconds", "The duration that borrower blocked for waiting objects."), func(h pond.PoolHistograms) pond.Histogram { return h.BorrowWait }},
+			{desc("create_seconds", "The duration of ObjectCreateFactory."), func(h pond.PoolHistograms) pond.Histogram { return h.Create }},
+			{desc("validate_seconds", "The duration of ObjectValidateFactory."), func(h pond.PoolHistograms) pond.Histogram { return h.Validate }},
+			{desc("destroy_seconds", "The duration of ObjectDestroyFactory."), func(h pond.PoolHistograms) pond.Histogram { return h.Destroy }},
+		},
+	}
+}
+
+//Register add the pool to be collected with the name as label
+func (c *Collector) Register(name string, pool StatsProvider) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if _, ok := c.pools[name]; ok {
+		return ErrPoolRegistered
+	}
+	c.pools[name] = pool
+	return nil
+}
+
+//Unregister remove the pool, return false if not registered
+func (c *Collector) Unregister(name string) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if _, ok := c.pools[name]; !ok {
+		return false
+	}
+	delete(c.pools, name)
+	return true
+}
+
+//Describe implements prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prom.Desc) {
+	for _, m := range c.gauges {
+		ch <- m.desc
+	}
+	for _, m := range c.counters {
+		ch <- m.desc
+	}
+	for _, m := range c.histograms {
+		ch <- m.desc
+	}
+}
+
+//Collect implements prometheus.Collector
+func (c *Collector) Collect(ch chan<- prom.Metric) {
+	c.lock.RLock()
+	names := make([]string, 0, len(c.pools))
+	for name := range c.pools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pools := make([]StatsProvider, len(names))
+	for i, name := range names {
+		pools[i] = c.pools[name]
+	}
+	c.lock.RUnlock()
+
+	for i, pool := range pools {
+		name := names[i]
+		stats := pool.Stats()
+		for _, m := range c.gauges {
+			ch <- prom.MustNewConstMetric(m.desc, prom.GaugeValue, m.value(stats), name)
+		}
+		for _, m := range c.counters {
+			ch <- prom.MustNewConstMetric(m.desc, prom.CounterValue, m.value(stats), name)
+		}
+		histograms := pool.Histograms()
+		for _, m := range c.histograms {
+			count, sum, buckets := convertHistogram(m.value(histograms))
+			ch <- prom.MustNewConstHistogram(m.desc, count, sum, buckets, name)
+		}
+	}
+}
+
+//convertHistogram convert to cumulative buckets in seconds, the unbounded bucket is implied by +Inf
+func convertHistogram(h pond.Histogram) (uint64, float64, map[float64]uint64) {
+	buckets := make(map[float64]uint64, len(h.Buckets))
+	var cumulative int64
+	for _, b := range h.Buckets {
+		cumulative += b.Count
+		if b.UpperBound == math.MaxInt64 {
+			continue
+		}
+		buckets[b.UpperBound.Seconds()] = uint64(cumulative)
+	}
+	return uint64(h.Count), h.Sum.Seconds(), buckets
+}
+
+//limit report no limit as 0
+func limit(size int) int {
+	if size < 0 {
+		return 0
+	}
+	return size
+}
+
+var _ prom.Collector = (*Collector)(nil)
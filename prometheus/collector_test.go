@@ -0,0 +1,100 @@
+package prometheus
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/joway/pond"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestPool(t *testing.T, maxSize int) *pond.Pool {
+	cfg := pond.NewConfig(func(ctx context.Context) (interface{}, error) {
+		return new(int), nil
+	})
+	cfg.MaxSize = maxSize
+	cfg.AutoEvict = false
+	p, err := pond.New(cfg)
+	assert.NoError(t, err)
+	return p
+}
+
+func TestCollector(t *testing.T) {
+	ctx := context.Background()
+	p1 := newTestPool(t, 5)
+	defer p1.Close(ctx)
+	p2 := newTestPool(t, 3)
+	defer p2.Close(ctx)
+
+	c := NewCollector("")
+	assert.NoError(t, c.Register("p1", p1))
+	assert.NoError(t, c.Register("p2", p2))
+	assert.Equal(t, ErrPoolRegistered, c.Register("p1", p1))
+
+	reg := prom.NewPedanticRegistry()
+	assert.NoError(t, reg.Register(c))
+
+	obj, err := p1.BorrowObject(ctx)
+	assert.NoError(t, err)
+	_, err = p1.BorrowObject(ctx)
+	assert.NoError(t, err)
+	assert.NoError(t, p1.ReturnObject(ctx, obj))
+
+	expected := `
+# HELP pond_pool_active_objects The current number of active objects.
+# TYPE pond_pool_active_objects gauge
+pond_pool_active_objects{pool="p1"} 1
+pond_pool_active_objects{pool="p2"} 0
+# HELP pond_pool_idle_objects The current number of idle objects.
+# TYPE pond_pool_idle_objects gauge
+pond_pool_idle_objects{pool="p1"} 1
+pond_pool_idle_objects{pool="p2"} 0
+# HELP pond_pool_max_size The capacity of the pool, 0 means no limit.
+# TYPE pond_pool_max_size gauge
+pond_pool_max_size{pool="p1"} 5
+pond_pool_max_size{pool="p2"} 3
+# HELP pond_pool_borrows_total The number of objects borrowed successfully.
+# TYPE pond_pool_borrows_total counter
+pond_pool_borrows_total{pool="p1"} 2
+pond_pool_borrows_total{pool="p2"} 0
+# HELP pond_pool_creates_total The number of objects created.
+# TYPE pond_pool_creates_total counter
+pond_pool_creates_total{pool="p1"} 2
+pond_pool_creates_total{pool="p2"} 0
+`
+	err = testutil.GatherAndCompare(reg, strings.NewReader(expected),
+		"pond_pool_active_objects", "pond_pool_idle_objects", "pond_pool_max_size",
+		"pond_pool_borrows_total", "pond_pool_creates_total",
+	)
+	assert.NoError(t, err)
+
+	//histograms
+	count, err := testutil.GatherAndCount(reg, "pond_pool_create_seconds")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	assert.True(t, c.Unregister("p2"))
+	assert.False(t, c.Unregister("p2"))
+	count, err = testutil.GatherAndCount(reg, "pond_pool_objects")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestConvertHistogram(t *testing.T) {
+	h := pond.Histogram{
+		Buckets: []pond.HistogramBucket{
+			{UpperBound: 1000000, Count: 1},
+			{UpperBound: 2000000, Count: 2},
+			{UpperBound: 1<<63 - 1, Count: 3},
+		},
+		Count: 6,
+		Sum:   6000000000,
+	}
+	count, sum, buckets := convertHistogram(h)
+	assert.Equal(t, uint64(6), count)
+	assert.Equal(t, 6.0, sum)
+	assert.Equal(t, map[float64]uint64{0.001: 1, 0.002: 3}, buckets)
+}
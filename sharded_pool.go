@@ -0,0 +1,349 @@
+package pond
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//ShardedPool is a sharded pool of interface{} objects. It's a thin wrapper of TypedShardedPool[interface{}].
+type ShardedPool struct {
+	*TypedShardedPool[interface{}]
+}
+
+//TypedShardedPool is a thread-safe pool of T objects split into shards, so that borrowers on many cores don't contend for one lock.
+//Each shard has its own idle objects and lock, borrowers steal idle objects from other shards when the local one is empty,
+//and all of them share the MaxSize.
+type TypedShardedPool[T any] struct {
+	config  TypedConfig[T]
+	budget  *budget
+	shards  []*TypedPool[T]
+	next    uint32    //round-robin index of local shard
+	locals  sync.Pool //local shard of current core, so that borrowers on the same core prefer the same shard
+	waiting int32     //number of borrowers missed idle objects
+	pending []int32   //number of borrowers missed idle objects by shard
+	owners  sync.Map  //owner shards of active objects borrowed by value
+
+	lock          sync.Mutex //lock for closed and evictor
+	closed        bool
+	evictorTicker *time.Ticker
+	evictorStop   chan struct{}
+	evictorGroup  sync.WaitGroup
+}
+
+//NewSharded create a sharded pool by config. If shards <= 0, it's GOMAXPROCS.
+func NewSharded(config Config, shards int) (*ShardedPool, error) {
+	s, err := NewTypedSharded(config, shards)
+	if err != nil {
+		return nil, err
+	}
+	return &ShardedPool{TypedShardedPool: s}, nil
+}
+
+//NewTypedSharded create a typed sharded pool by config. If shards <= 0, it's GOMAXPROCS.
+//MaxSize is shared by all shards, MinIdle and MaxIdle are split across shards evenly.
+func NewTypedSharded[T any](config TypedConfig[T], shards int) (*TypedShardedPool[T], error) {
+	if config.ObjectCreateFactory == nil {
+		return nil, ErrObjectCreateFactoryNotFound
+	}
+	if shards <= 0 {
+		shards = runtime.GOMAXPROCS(0)
+	}
+	s := &TypedShardedPool[T]{
+		config:  config,
+		budget:  newBudget(config.MaxSize),
+		shards:  make([]*TypedPool[T], shards),
+		pending: make([]int32, shards),
+	}
+	s.locals.New = func() interface{} {
+		local := atomic.AddUint32(&s.next, 1) % uint32(shards)
+		return &local
+	}
+	for i := range s.shards {
+		shardConfig := config
+		//limited by the shared budget
+		shardConfig.MaxSize = 0
+		shardConfig.MinIdle = splitShare(config.MinIdle, shards, i)
+		shardConfig.MaxIdle = splitShare(config.MaxIdle, shards, i)
+		//all shards are evicted by sharded pool
+		shardConfig.AutoEvict = false
+		p, _ := newTypedPool(shardConfig, s.budget)
+		p.group = s
+		s.shards[i] = p
+	}
+	if config.AutoEvict {
+		s.evictorTicker = time.NewTicker(config.EvictInterval)
+		s.evictorStop = make(chan struct{})
+		s.evictorGroup.Add(1)
+		go func() {
+			defer s.evictorGroup.Done()
+			s.StartEvictor()
+		}()
+	}
+	return s, nil
+}
+
+//splitShare return the share of the ith shard when n is split across shards, the remainder is spread across the first shards
+func splitShare(n, shards, i int) int {
+	share := n / shards
+	if i < n%shards {
+		share++
+	}
+	return share
+}
+
+//BorrowObject promise to return a idle object. It will be blocked when there is no any idle object.
+func (s *ShardedPool) BorrowObject(ctx context.Context, opts ...BorrowOption) (interface{}, error) {
+	return s.Borrow(ctx, opts...)
+}
+
+//ReturnObject return the borrowed object to pool
+func (s *ShardedPool) ReturnObject(ctx context.Context, object interface{}) error {
+	return s.Return(ctx, object)
+}
+
+//InvalidateObject delete and destroy the active object
+func (s *ShardedPool) InvalidateObject(ctx context.Context, object interface{}) error {
+	return s.Invalidate(ctx, object)
+}
+
+//Borrow promise to return a idle object. It will be blocked when there is no any idle object.
+//The object is tracked by value, so it must be comparable. BorrowHandle is cheaper, since the lease knows its shard.
+func (s *TypedShardedPool[T]) Borrow(ctx context.Context, opts ...BorrowOption) (T, error) {
+	var zero T
+	p, po, err := s.borrow(ctx, true, newBorrowOptions(opts))
+	if err != nil {
+		return zero, err
+	}
+	object := po.Object()
+	s.owners.Store(interface{}(object), p)
+	return object, nil
+}
+
+//BorrowHandle borrow a object owned by the returned lease. Any type of object could be borrowed by lease.
+func (s *TypedShardedPool[T]) BorrowHandle(ctx context.Context, opts ...BorrowOption) (*Lease[T], error) {
+	p, po, err := s.borrow(ctx, false, newBorrowOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+	return newLease(p, po), nil
+}
+
+//borrow borrow a idle object of local shard, or steal one from other shards.
+//If there is no any idle object, it creates or waits in local shard.
+func (s *TypedShardedPool[T]) borrow(ctx context.Context, track bool, o borrowOptions) (*TypedPool[T], *pooledObject[T], error) {
+	n := uint32(len(s.shards))
+	hint := s.locals.Get().(*uint32)
+	local := *hint
+	s.locals.Put(hint)
+	idleOnly := o
+	idleOnly.noCreate = true
+	p := s.shards[local]
+	if po, err := p.borrow(ctx, track, idleOnly); err != ErrNoIdleObject {
+		return p, po, err
+	}
+
+	//the objects returned to other shards will be moved to local shard from now on
+	atomic.AddInt32(&s.waiting, 1)
+	atomic.AddInt32(&s.pending[local], 1)
+	defer func() {
+		atomic.AddInt32(&s.pending[local], -1)
+		atomic.AddInt32(&s.waiting, -1)
+	}()
+	for i := uint32(1); i < n; i++ {
+		victim := s.shards[(local+i)%n]
+		if po, err := victim.borrow(ctx, track, idleOnly); err != ErrNoIdleObject {
+			return victim, po, err
+		}
+	}
+	po, err := p.borrow(ctx, track, o)
+	return p, po, err
+}
+
+//rebalance move the idle objects of shard to the shards having borrowers missed idle objects
+func (s *TypedShardedPool[T]) rebalance(ctx context.Context, from *TypedPool[T]) {
+	if atomic.LoadInt32(&s.waiting) == 0 {
+		return
+	}
+	for i, p := range s.shards {
+		if p == from || atomic.LoadInt32(&s.pending[i]) == 0 {
+			continue
+		}
+		po := from.transferIdle()
+		if po == nil {
+			return
+		}
+		p.adopt(ctx, po)
+	}
+}
+
+//Return return the borrowed object to pool
+func (s *TypedShardedPool[T]) Return(ctx context.Context, object T) error {
+	p, found, err := s.withOwner(object, func(p *TypedPool[T]) (bool, error) {
+		return p.returnTracked(ctx, object)
+	})
+	if found {
+		return err
+	}
+	if p.destroysUnknown() {
+		//if return after closing, just destroy object
+		return p.destroyObject(ctx, object)
+	}
+	//return a object that not existed
+	return nil
+}
+
+//Invalidate delete and destroy the active object
+func (s *TypedShardedPool[T]) Invalidate(ctx context.Context, object T) error {
+	p, found, err := s.withOwner(object, func(p *TypedPool[T]) (bool, error) {
+		return p.invalidateTracked(ctx, object)
+	})
+	if found {
+		return err
+	}
+	//destroy the object even if it's not found
+	return p.destroyObject(ctx, object)
+}
+
+//withOwner call fn with the owner shard of object, or all shards until found if the owner is unknown.
+//It returns the shard called at last.
+func (s *TypedShardedPool[T]) withOwner(object T, fn func(p *TypedPool[T]) (bool, error)) (*TypedPool[T], bool, error) {
+	var owner *TypedPool[T]
+	if isComparable(object) {
+		if v, ok := s.owners.LoadAndDelete(interface{}(object)); ok {
+			owner = v.(*TypedPool[T])
+			if found, err := fn(owner); found {
+				return owner, true, err
+			}
+		}
+	}
+	//objects equal in value may be borrowed from different shards
+	p := s.shards[0]
+	for _, p = range s.shards {
+		if p == owner {
+			continue
+		}
+		if found, err := fn(p); found {
+			return p, true, err
+		}
+	}
+	return p, false, nil
+}
+
+//ActiveSize return the number of active objects of all shards
+func (s *TypedShardedPool[T]) ActiveSize() int {
+	size := 0
+	for _, p := range s.shards {
+		size += p.ActiveSize()
+	}
+	return size
+}
+
+//IdleSize return the number of idle objects of all shards
+func (s *TypedShardedPool[T]) IdleSize() int {
+	size := 0
+	for _, p := range s.shards {
+		size += p.IdleSize()
+	}
+	return size
+}
+
+//Size return the number of objects of all shards
+func (s *TypedShardedPool[T]) Size() int {
+	size := 0
+	for _, p := range s.shards {
+		size += p.Size()
+	}
+	return size
+}
+
+//Shards return the number of shards
+func (s *TypedShardedPool[T]) Shards() int {
+	return len(s.shards)
+}
+
+//Stats return a snapshot of statistics summed over all shards
+func (s *TypedShardedPool[T]) Stats() PoolStats {
+	stats := PoolStats{}
+	for _, p := range s.shards {
+		stats.merge(p.Stats())
+	}
+	stats.MaxSize = s.config.MaxSize
+	return stats
+}
+
+//Histograms return snapshots of latency histograms merged over all shards
+func (s *TypedShardedPool[T]) Histograms() PoolHistograms {
+	histograms := PoolHistograms{}
+	for _, p := range s.shards {
+		histograms.merge(p.Histograms())
+	}
+	return histograms
+}
+
+//Evict evict idle objects of all shards
+func (s *TypedShardedPool[T]) Evict(ctx context.Context) error {
+	var evictErr error
+	for _, p := range s.shards {
+		if err := p.Evict(ctx); err != nil && evictErr == nil {
+			evictErr = err
+		}
+	}
+	return evictErr
+}
+
+func (s *TypedShardedPool[T]) StartEvictor() {
+	for {
+		select {
+		case <-s.evictorTicker.C:
+			_ = s.Evict(context.Background())
+		case <-s.evictorStop:
+			return
+		}
+	}
+}
+
+//Close close all shards. The listener is notified of closing by each shard.
+func (s *TypedShardedPool[T]) Close(ctx context.Context) error {
+	if !s.close() {
+		return ErrPoolClosed
+	}
+	for _, p := range s.shards {
+		_ = p.Close(ctx)
+	}
+	return nil
+}
+
+//Shutdown shutdown all shards, and report the number of objects destroyed forcibly
+func (s *TypedShardedPool[T]) Shutdown(ctx context.Context) (int, error) {
+	s.close()
+	defer s.evictorGroup.Wait()
+
+	forced := 0
+	var shutdownErr error
+	for _, p := range s.shards {
+		n, err := p.Shutdown(ctx)
+		forced += n
+		if err != nil {
+			shutdownErr = err
+		}
+	}
+	return forced, shutdownErr
+}
+
+//close stop the evictor, and report whether it's closed by this call
+func (s *TypedShardedPool[T]) close() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.closed {
+		return false
+	}
+	s.closed = true
+	if s.evictorTicker != nil {
+		s.evictorTicker.Stop()
+		close(s.evictorStop)
+	}
+	return true
+}
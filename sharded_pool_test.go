@@ -0,0 +1,214 @@
+package pond
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestShardedPool(maxSize, shards int) *ShardedPool {
+	cfg := NewConfig(testObjectCreateFactory)
+	cfg.MaxSize = maxSize
+	cfg.AutoEvict = false
+	p, _ := NewSharded(cfg, shards)
+	return p
+}
+
+func TestShardedPoolSplitIdle(t *testing.T) {
+	for _, n := range []int{0, 1, 7, 8, 13} {
+		cfg := NewConfig(testObjectCreateFactory)
+		cfg.AutoEvict = false
+		cfg.MinIdle = n / 2
+		cfg.MaxIdle = n
+		p, err := NewSharded(cfg, 8)
+		assert.NoError(t, err)
+		minIdle, maxIdle := 0, 0
+		for _, shard := range p.shards {
+			assert.True(t, shard.config.MinIdle <= shard.config.MaxIdle)
+			minIdle += shard.config.MinIdle
+			maxIdle += shard.config.MaxIdle
+		}
+		assert.Equal(t, cfg.MinIdle, minIdle)
+		assert.Equal(t, cfg.MaxIdle, maxIdle)
+		assert.NoError(t, p.Close(context.Background()))
+	}
+}
+
+func TestShardedPool(t *testing.T) {
+	ctx := context.Background()
+	cfg := NewConfig(testObjectCreateFactory)
+	cfg.MinIdle = 3
+	cfg.MaxIdle = 5
+	p, err := NewSharded(cfg, 2)
+	assert.NoError(t, err)
+	defer p.Close(ctx)
+	assert.Equal(t, 2, p.Shards())
+	assert.Equal(t, 2, p.shards[0].config.MinIdle)
+	assert.Equal(t, 1, p.shards[1].config.MinIdle)
+	assert.Equal(t, 3, p.shards[0].config.MaxIdle)
+	assert.Equal(t, 2, p.shards[1].config.MaxIdle)
+
+	obj, err := p.BorrowObject(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, p.ActiveSize())
+	assert.NoError(t, p.ReturnObject(ctx, obj))
+	assert.Equal(t, 0, p.ActiveSize())
+	assert.Equal(t, 1, p.IdleSize())
+	assert.Equal(t, 1, p.Size())
+
+	//warmup by shards
+	assert.NoError(t, p.Evict(ctx))
+	assert.Equal(t, 3, p.IdleSize())
+	stats := p.Stats()
+	assert.Equal(t, int64(1), stats.Borrows)
+	assert.Equal(t, int64(1), stats.Returns)
+	assert.Equal(t, int64(3), stats.Creates)
+	assert.Equal(t, DefaultMaxSize, stats.MaxSize)
+	assert.Equal(t, 3, stats.MinIdle)
+	assert.Equal(t, int64(3), p.Histograms().Create.Count)
+
+	_, err = NewSharded(NewDefaultConfig(), 2)
+	assert.Equal(t, ErrObjectCreateFactoryNotFound, err)
+	p2, _ := NewSharded(cfg, 0)
+	assert.True(t, p2.Shards() > 0)
+	assert.NoError(t, p2.Close(ctx))
+}
+
+func TestShardedPoolSteal(t *testing.T) {
+	ctx := context.Background()
+	p := newTestShardedPool(4, 4)
+	defer p.Close(ctx)
+
+	obj, _ := p.BorrowObject(ctx)
+	assert.NoError(t, p.ReturnObject(ctx, obj))
+	//the idle object is stolen by other shards
+	for i := 0; i < 10; i++ {
+		o, err := p.BorrowObject(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, obj, o)
+		assert.NoError(t, p.ReturnObject(ctx, o))
+	}
+	assert.Equal(t, int64(1), p.Stats().Creates)
+
+	lease, err := p.BorrowHandle(ctx, WithoutCreate())
+	assert.NoError(t, err)
+	assert.Equal(t, obj, lease.Object())
+	_, err = p.BorrowObject(ctx, WithoutCreate())
+	assert.Equal(t, ErrNoIdleObject, err)
+	assert.NoError(t, lease.Release(ctx))
+	assert.Equal(t, 1, p.IdleSize())
+}
+
+func TestShardedPoolMaxSize(t *testing.T) {
+	ctx := context.Background()
+	p := newTestShardedPool(2, 4)
+	defer p.Close(ctx)
+
+	objs := make([]interface{}, 2)
+	for i := range objs {
+		objs[i], _ = p.BorrowObject(ctx)
+	}
+	_, err := p.BorrowObject(ctx, WithNonblocking())
+	assert.Equal(t, ErrPoolExhausted, err)
+
+	//the returned objects are moved to the shards of waiters
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			obj, err := p.BorrowObject(ctx, WithMaxWait(time.Second))
+			assert.NoError(t, err)
+			assert.NoError(t, p.ReturnObject(ctx, obj))
+		}()
+	}
+	time.Sleep(time.Millisecond * 10)
+	assert.Equal(t, 2, p.Stats().Waiters)
+	for _, obj := range objs {
+		assert.NoError(t, p.ReturnObject(ctx, obj))
+	}
+	wg.Wait()
+	assert.Equal(t, 2, p.Size())
+	assert.Equal(t, int64(2), p.Stats().Creates)
+}
+
+func TestShardedPoolConcurrent(t *testing.T) {
+	ctx := context.Background()
+	p := newTestShardedPool(3, 4)
+	defer p.Close(ctx)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				if i%2 == 0 {
+					obj, err := p.BorrowObject(ctx, WithMaxWait(time.Second*5))
+					assert.NoError(t, err)
+					assert.NoError(t, p.ReturnObject(ctx, obj))
+					continue
+				}
+				lease, err := p.BorrowHandle(ctx, WithMaxWait(time.Second*5))
+				assert.NoError(t, err)
+				assert.NoError(t, lease.Release(ctx))
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.True(t, p.Size() <= 3)
+	assert.Equal(t, 0, p.ActiveSize())
+	assert.Equal(t, int64(1600), p.Stats().Borrows)
+}
+
+func TestShardedPoolInvalidate(t *testing.T) {
+	ctx := context.Background()
+	p := newTestShardedPool(2, 2)
+
+	obj, _ := p.BorrowObject(ctx)
+	assert.NoError(t, p.InvalidateObject(ctx, obj))
+	assert.Equal(t, 0, p.Size())
+	assert.Equal(t, int64(1), p.Stats().Invalidations)
+	assert.Equal(t, 0, p.budget.Used())
+
+	//the owner is unknown
+	obj, _ = p.BorrowObject(ctx)
+	p.owners.Delete(obj)
+	assert.NoError(t, p.ReturnObject(ctx, obj))
+	assert.Equal(t, 1, p.IdleSize())
+
+	obj, _ = p.BorrowObject(ctx)
+	assert.NoError(t, p.Close(ctx))
+	assert.Equal(t, ErrPoolClosed, p.Close(ctx))
+	assert.NoError(t, p.ReturnObject(ctx, obj))
+	assert.Equal(t, 0, p.Size())
+	assert.Equal(t, int64(2), p.Stats().Destroys)
+	_, err := p.BorrowObject(ctx)
+	assert.Equal(t, ErrPoolClosed, err)
+}
+
+func TestShardedPoolShutdown(t *testing.T) {
+	ctx := context.Background()
+	cfg := NewConfig(testObjectCreateFactory)
+	cfg.EvictInterval = time.Millisecond
+	p, _ := NewSharded(cfg, 2)
+
+	obj, _ := p.BorrowObject(ctx)
+	lease, _ := p.BorrowHandle(ctx)
+	go func() {
+		time.Sleep(time.Millisecond * 10)
+		_ = p.ReturnObject(ctx, obj)
+	}()
+	cctx, cancel := context.WithTimeout(ctx, time.Millisecond*50)
+	defer cancel()
+	forced, err := p.Shutdown(cctx)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, 1, forced)
+	//destroyed forcibly
+	assert.NoError(t, lease.Release(ctx))
+	assert.Equal(t, int64(2), p.Stats().Destroys)
+	assert.Equal(t, 0, p.Size())
+}
@@ -0,0 +1,110 @@
+package pond
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+//PoolStats is a snapshot of pool statistics. The counters are cumulative since the pool created.
+type PoolStats struct {
+	//Borrows is the number of objects borrowed successfully
+	Borrows int64
+	//Returns is the number of objects returned
+	Returns int64
+	//Creates is the number of objects created
+	Creates int64
+	//CreateFailures is the number of errors returned by ObjectCreateFactory
+	CreateFailures int64
+	//Destroys is the number of objects destroyed
+	Destroys int64
+	//ValidateFailures is the number of objects failed to validate when borrowed, returned or idle
+	ValidateFailures int64
+	//Evictions is the number of idle objects evicted by evictor
+	Evictions int64
+	//Abandoned is the number of objects detected as abandoned
+	Abandoned int64
+	//Invalidations is the number of objects invalidated by borrower
+	Invalidations int64
+	//BorrowWaits is the number of times that borrower waited for a object
+	BorrowWaits int64
+	//BorrowTimeouts is the number of waits ended by context canceled, deadline exceeded or max wait exceeded
+	BorrowTimeouts int64
+	//Exhausted is the number of borrows rejected by ErrPoolExhausted
+	Exhausted int64
+	//WaitDuration is the total duration that borrowers waited
+	WaitDuration time.Duration
+
+	//Waiters is the current number of blocked borrowers
+	Waiters int
+	//Active is the current number of active objects
+	Active int
+	//Idle is the current number of idle objects
+	Idle int
+
+	//MaxSize is the current MaxSize of config
+	MaxSize int
+	//MinIdle is the current MinIdle of config
+	MinIdle int
+	//MaxIdle is the current MaxIdle of config
+	MaxIdle int
+}
+
+//poolCounters is updated atomically, so it's cheap enough to be always on
+type poolCounters struct {
+	borrows          int64
+	returns          int64
+	creates          int64
+	createFailures   int64
+	destroys         int64
+	validateFailures int64
+	evictions        int64
+	abandoned        int64
+	invalidations    int64
+	borrowWaits      int64
+	borrowTimeouts   int64
+	exhausted        int64
+	waitDuration     int64
+}
+
+func (c *poolCounters) add(counter *int64, delta int64) {
+	atomic.AddInt64(counter, delta)
+}
+
+func (c *poolCounters) load(stats *PoolStats) {
+	stats.Borrows = atomic.LoadInt64(&c.borrows)
+	stats.Returns = atomic.LoadInt64(&c.returns)
+	stats.Creates = atomic.LoadInt64(&c.creates)
+	stats.CreateFailures = atomic.LoadInt64(&c.createFailures)
+	stats.Destroys = atomic.LoadInt64(&c.destroys)
+	stats.ValidateFailures = atomic.LoadInt64(&c.validateFailures)
+	stats.Evictions = atomic.LoadInt64(&c.evictions)
+	stats.Abandoned = atomic.LoadInt64(&c.abandoned)
+	stats.Invalidations = atomic.LoadInt64(&c.invalidations)
+	stats.BorrowWaits = atomic.LoadInt64(&c.borrowWaits)
+	stats.BorrowTimeouts = atomic.LoadInt64(&c.borrowTimeouts)
+	stats.Exhausted = atomic.LoadInt64(&c.exhausted)
+	stats.WaitDuration = time.Duration(atomic.LoadInt64(&c.waitDuration))
+}
+
+//merge accumulate the counters and sizes of other pool, e.g. a shard. The limits of config are summed too.
+func (s *PoolStats) merge(o PoolStats) {
+	s.Borrows += o.Borrows
+	s.Returns += o.Returns
+	s.Creates += o.Creates
+	s.CreateFailures += o.CreateFailures
+	s.Destroys += o.Destroys
+	s.ValidateFailures += o.ValidateFailures
+	s.Evictions += o.Evictions
+	s.Abandoned += o.Abandoned
+	s.Invalidations += o.Invalidations
+	s.BorrowWaits += o.BorrowWaits
+	s.BorrowTimeouts += o.BorrowTimeouts
+	s.Exhausted += o.Exhausted
+	s.WaitDuration += o.WaitDuration
+	s.Waiters += o.Waiters
+	s.Active += o.Active
+	s.Idle += o.Idle
+	s.MaxSize += o.MaxSize
+	s.MinIdle += o.MinIdle
+	s.MaxIdle += o.MaxIdle
+}
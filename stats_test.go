@@ -0,0 +1,92 @@
+package pond
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPoolStats(t *testing.T) {
+	ctx := context.Background()
+	cfg := NewConfig(testObjectCreateFactory)
+	cfg.MaxSize = 2
+	cfg.MaxIdle = 0
+	cfg.MinIdleTime = 0
+	cfg.AutoEvict = false
+	cfg.ObjectValidateFactory = testObjectValidateFactory
+	p, _ := New(cfg)
+	defer p.Close(ctx)
+
+	obj1, err := p.BorrowObject(ctx)
+	assert.NoError(t, err)
+	obj2, err := p.BorrowObject(ctx)
+	assert.NoError(t, err)
+
+	//wait timeout
+	cctx, cancel := context.WithTimeout(ctx, time.Millisecond*10)
+	defer cancel()
+	_, err = p.BorrowObject(cctx)
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	stats := p.Stats()
+	assert.Equal(t, int64(2), stats.Borrows)
+	assert.Equal(t, int64(2), stats.Creates)
+	assert.Equal(t, int64(1), stats.BorrowWaits)
+	assert.Equal(t, int64(1), stats.BorrowTimeouts)
+	assert.True(t, stats.WaitDuration >= time.Millisecond*10)
+	assert.Equal(t, 2, stats.Active)
+	assert.Equal(t, 2, stats.MaxSize)
+	assert.Equal(t, 0, stats.MaxIdle)
+
+	//waiter
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		obj, err := p.BorrowObject(ctx)
+		assert.NoError(t, err)
+		assert.NoError(t, p.ReturnObject(ctx, obj))
+	}()
+	time.Sleep(time.Millisecond * 10)
+	assert.Equal(t, 1, p.Stats().Waiters)
+	assert.NoError(t, p.ReturnObject(ctx, obj1))
+	<-done
+	assert.NoError(t, p.InvalidateObject(ctx, obj2))
+
+	//evict
+	assert.NoError(t, p.Evict(ctx))
+	assert.Equal(t, int64(1), p.Stats().Evictions)
+
+	//create and validate failures
+	_, err = p.BorrowObject(context.WithValue(ctx, contextKeyCreateErr{}, errors.New("create error")))
+	assert.Error(t, err)
+	_, err = p.BorrowObject(context.WithValue(ctx, contextKeyValidateErr{}, "failed"))
+	assert.Equal(t, ErrObjectValidateFailed, err)
+
+	stats = p.Stats()
+	assert.Equal(t, int64(3), stats.Borrows)
+	assert.Equal(t, int64(2), stats.Returns)
+	assert.Equal(t, int64(1), stats.Invalidations)
+	assert.Equal(t, int64(1), stats.CreateFailures)
+	assert.Equal(t, int64(2), stats.ValidateFailures)
+	assert.Equal(t, int64(2), stats.BorrowWaits)
+	assert.Equal(t, int64(4), stats.Creates)
+	assert.Equal(t, int64(4), stats.Destroys)
+	assert.Equal(t, 0, stats.Waiters)
+	assert.Equal(t, 0, stats.Active)
+	assert.Equal(t, 0, stats.Idle)
+
+	//exhausted
+	p.config.Nonblocking = true
+	obj1, _ = p.BorrowObject(ctx)
+	obj2, _ = p.BorrowObject(ctx)
+	_, err = p.BorrowObject(ctx)
+	assert.Equal(t, ErrPoolExhausted, err)
+	assert.Equal(t, int64(1), p.Stats().Exhausted)
+	assert.NoError(t, p.ReturnObject(ctx, obj1))
+	assert.NoError(t, p.ReturnObject(ctx, obj2))
+	assert.NoError(t, p.Evict(ctx))
+	assert.Equal(t, int64(3), p.Stats().Evictions)
+}
@@ -0,0 +1,54 @@
+package pond
+
+import (
+	"context"
+)
+
+//span names
+const (
+	SpanWait     = "pond.wait"
+	SpanCreate   = "pond.create"
+	SpanValidate = "pond.validate"
+)
+
+//attribute keys
+const (
+	//AttrPoolName is the Name of pool config
+	AttrPoolName = "pond.pool.name"
+	//AttrObjectFresh is true if the borrowed object has never been borrowed before, it's set on the validate span
+	AttrObjectFresh = "pond.object.fresh"
+)
+
+//Attribute is a key-value pair of span. Value is a string, bool, int64 or float64.
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+//Tracer start spans of the wait, create and validate phases in borrowing.
+//It's shaped as OpenTelemetry, so that an adapter is trivial without depending on it.
+type Tracer interface {
+	//Start create a child span of the span in ctx, and return the ctx carrying the new span
+	Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span)
+}
+
+//Span is a traced phase started by Tracer
+type Span interface {
+	SetAttributes(attrs ...Attribute)
+	RecordError(err error)
+	End()
+}
+
+//startSpan start a span with the pool name. It must be called only if Tracer configured.
+func (p *TypedPool[T]) startSpan(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span) {
+	attrs = append(attrs, Attribute{Key: AttrPoolName, Value: p.config.Name})
+	return p.config.Tracer.Start(ctx, name, attrs...)
+}
+
+//endSpan end the span with the error if not nil
+func endSpan(span Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
+}
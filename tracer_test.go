@@ -0,0 +1,140 @@
+package pond
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testSpan struct {
+	name   string
+	attrs  map[string]interface{}
+	err    error
+	parent *testSpan
+	ended  bool
+}
+
+func (s *testSpan) SetAttributes(attrs ...Attribute) {
+	for _, attr := range attrs {
+		s.attrs[attr.Key] = attr.Value
+	}
+}
+
+func (s *testSpan) RecordError(err error) {
+	s.err = err
+}
+
+func (s *testSpan) End() {
+	s.ended = true
+}
+
+type contextKeySpan struct{}
+
+//testTracer records the spans started
+type testTracer struct {
+	lock  sync.Mutex
+	spans []*testSpan
+}
+
+func (t *testTracer) Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span) {
+	span := &testSpan{name: name, attrs: make(map[string]interface{})}
+	span.SetAttributes(attrs...)
+	span.parent, _ = ctx.Value(contextKeySpan{}).(*testSpan)
+	t.lock.Lock()
+	t.spans = append(t.spans, span)
+	t.lock.Unlock()
+	return context.WithValue(ctx, contextKeySpan{}, span), span
+}
+
+func (t *testTracer) Spans() []*testSpan {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	spans := t.spans
+	t.spans = nil
+	return spans
+}
+
+func TestPoolTracer(t *testing.T) {
+	root := &testSpan{name: "root"}
+	ctx := context.WithValue(context.Background(), contextKeySpan{}, root)
+	tracer := &testTracer{}
+	cfg := NewConfig(testObjectCreateFactory)
+	cfg.Name = "test"
+	cfg.MaxSize = 1
+	cfg.AutoEvict = false
+	cfg.ObjectValidateFactory = testObjectValidateFactory
+	cfg.Tracer = tracer
+	p, _ := New(cfg)
+	defer p.Close(ctx)
+
+	//fresh
+	obj, err := p.BorrowObject(ctx)
+	assert.NoError(t, err)
+	spans := tracer.Spans()
+	assert.Len(t, spans, 2)
+	assert.Equal(t, SpanCreate, spans[0].name)
+	assert.Equal(t, SpanValidate, spans[1].name)
+	assert.Equal(t, true, spans[1].attrs[AttrObjectFresh])
+	for _, span := range spans {
+		assert.Equal(t, root, span.parent)
+		assert.Equal(t, "test", span.attrs[AttrPoolName])
+		assert.True(t, span.ended)
+		assert.NoError(t, span.err)
+	}
+
+	//wait timeout
+	cctx, cancel := context.WithTimeout(ctx, time.Millisecond*10)
+	defer cancel()
+	_, err = p.BorrowObject(cctx)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	spans = tracer.Spans()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, SpanWait, spans[0].name)
+	assert.Equal(t, context.DeadlineExceeded, spans[0].err)
+
+	//reused
+	assert.NoError(t, p.ReturnObject(ctx, obj))
+	_, err = p.BorrowObject(context.WithValue(ctx, contextKeyValidateErr{}, "failed"))
+	assert.Equal(t, ErrObjectValidateFailed, err)
+	spans = tracer.Spans()
+	assert.Len(t, spans, 3)
+	assert.Equal(t, SpanValidate, spans[0].name)
+	assert.Equal(t, false, spans[0].attrs[AttrObjectFresh])
+	assert.Equal(t, ErrObjectValidateFailed, spans[0].err)
+	assert.Equal(t, SpanCreate, spans[1].name)
+	assert.Equal(t, SpanValidate, spans[2].name)
+	assert.Equal(t, true, spans[2].attrs[AttrObjectFresh])
+
+	//create failed
+	createErr := errors.New("create error")
+	_, err = p.BorrowObject(context.WithValue(ctx, contextKeyCreateErr{}, createErr))
+	assert.Equal(t, createErr, err)
+	spans = tracer.Spans()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, createErr, spans[0].err)
+}
+
+func TestPoolTracerDefaultValidate(t *testing.T) {
+	ctx := context.Background()
+	tracer := &testTracer{}
+	cfg := NewConfig(testObjectCreateFactory)
+	cfg.AutoEvict = false
+	cfg.Tracer = tracer
+	p, _ := New(cfg)
+	defer p.Close(ctx)
+
+	//fresh and reused are reported without validating
+	for _, fresh := range []bool{true, false} {
+		obj, err := p.BorrowObject(ctx)
+		assert.NoError(t, err)
+		assert.NoError(t, p.ReturnObject(ctx, obj))
+		spans := tracer.Spans()
+		assert.Equal(t, SpanValidate, spans[len(spans)-1].name)
+		assert.Equal(t, fresh, spans[len(spans)-1].attrs[AttrObjectFresh])
+	}
+	assert.Equal(t, int64(0), p.Histograms().Validate.Count)
+}
@@ -0,0 +1,80 @@
+package pond
+
+import (
+	"sync/atomic"
+)
+
+//waitResult is what a waiter is waken with.
+//Either an object handed off, or a slot reserved to create a new object.
+//The zero value means the waiter should retry, e.g. the pool has been closed.
+type waitResult[T any] struct {
+	po   *pooledObject[T]
+	slot bool
+}
+
+type waiter[T any] struct {
+	ch         chan waitResult[T] //buffered, waking up never blocked
+	prev, next *waiter[T]
+	queued     bool
+}
+
+//waiterQueue is a FIFO queue of blocked borrowers. It is not thread-safe, except Len.
+type waiterQueue[T any] struct {
+	head, tail *waiter[T]
+	size       int64 //changed atomically, so that returning without lock could find waiters
+}
+
+func newWaiterQueue[T any]() *waiterQueue[T] {
+	return &waiterQueue[T]{}
+}
+
+func (q *waiterQueue[T]) Len() int {
+	return int(atomic.LoadInt64(&q.size))
+}
+
+//Enqueue append a new waiter to the tail
+func (q *waiterQueue[T]) Enqueue() *waiter[T] {
+	w := &waiter[T]{
+		ch:     make(chan waitResult[T], 1),
+		prev:   q.tail,
+		queued: true,
+	}
+	if q.tail == nil {
+		q.head = w
+	} else {
+		q.tail.next = w
+	}
+	q.tail = w
+	atomic.AddInt64(&q.size, 1)
+	return w
+}
+
+//Dequeue remove the longest-waiting waiter
+func (q *waiterQueue[T]) Dequeue() *waiter[T] {
+	w := q.head
+	if w != nil {
+		q.Remove(w)
+	}
+	return w
+}
+
+//Remove remove the waiter if it's still queued
+func (q *waiterQueue[T]) Remove(w *waiter[T]) bool {
+	if !w.queued {
+		return false
+	}
+	if w.prev == nil {
+		q.head = w.next
+	} else {
+		w.prev.next = w.next
+	}
+	if w.next == nil {
+		q.tail = w.prev
+	} else {
+		w.next.prev = w.prev
+	}
+	w.prev, w.next = nil, nil
+	w.queued = false
+	atomic.AddInt64(&q.size, -1)
+	return true
+}
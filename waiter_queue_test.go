@@ -0,0 +1,43 @@
+package pond
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaiterQueue(t *testing.T) {
+	q := newWaiterQueue[interface{}]()
+	size := 100
+	assert.Equal(t, 0, q.Len())
+	assert.Nil(t, q.Dequeue())
+
+	//FIFO
+	waiters := make([]*waiter[interface{}], 0)
+	for i := 0; i < size; i++ {
+		waiters = append(waiters, q.Enqueue())
+	}
+	assert.Equal(t, size, q.Len())
+	for i := 0; i < size; i++ {
+		assert.Equal(t, waiters[i], q.Dequeue())
+		assert.False(t, waiters[i].queued)
+		assert.Equal(t, size-i-1, q.Len())
+	}
+	assert.Nil(t, q.Dequeue())
+
+	//remove
+	waiters = waiters[:0]
+	for i := 0; i < size; i++ {
+		waiters = append(waiters, q.Enqueue())
+	}
+	for i := 0; i < size; i += 2 {
+		assert.True(t, q.Remove(waiters[i]))
+		assert.False(t, q.Remove(waiters[i]))
+	}
+	assert.Equal(t, size/2, q.Len())
+	for i := 1; i < size; i += 2 {
+		assert.Equal(t, waiters[i], q.Dequeue())
+	}
+	assert.Equal(t, 0, q.Len())
+	assert.Nil(t, q.Dequeue())
+}